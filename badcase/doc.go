@@ -1,20 +1,10 @@
-package badcase
-
-// This section code not print x, because it's will running forever.
-// Why? when main goroutine sleep 1 second, go scheduler will start execute other sub-goroutine,
-// because sub-goroutine numbers equals os CUP numbers, as the same time, every sub-goroutine is not exit.
-// so every thread exec a goroutine, don't print x.
+// Package badcase is a catalog of concurrency pitfalls, every pitfall is an exported function
+// with a fixed counterpart named with suffix Fixed, the tests show the difference.
 //
-//func main() {
-//	var x int
-//	threads := runtime.GOMAXPROCS(0)
-//	for i := 0; i < threads; i++ {
-//		go func() {
-//			for {
-//				x++
-//			}
-//		}()
-//	}
-//	time.Sleep(time.Second)
-//	fmt.Println("x =", x)
-//}
+//	TightLoop           goroutines spin without preemption point starve the scheduler.
+//	FirstResponse       goroutines block for ever on an unbuffered channel nobody receives.
+//	CaptureLoopVar      closures capture the loop variable, not it's value.
+//	CountWords          concurrent writes on a map crash the process.
+//	SumAsync            sync.WaitGroup.Add in the new goroutine races with Wait.
+//	ConsumeUntilIdle    time.After in a select loop allocate a timer every iteration.
+package badcase
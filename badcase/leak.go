@@ -0,0 +1,26 @@
+package badcase
+
+// FirstResponse call the queries concurrently and return the first result.
+//
+// Only the first goroutine can send on the unbuffered channel, the others block for ever,
+// they and everything they reference leak.
+func FirstResponse(queries []func() string) string {
+	results := make(chan string)
+	for _, query := range queries {
+		go func(query func() string) {
+			results <- query()
+		}(query)
+	}
+	return <-results
+}
+
+// FirstResponseFixed buffer the channel for all results, so every goroutine can send and exit.
+func FirstResponseFixed(queries []func() string) string {
+	results := make(chan string, len(queries))
+	for _, query := range queries {
+		go func(query func() string) {
+			results <- query()
+		}(query)
+	}
+	return <-results
+}
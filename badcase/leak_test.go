@@ -0,0 +1,37 @@
+package badcase
+
+import (
+	"github.com/xy1884/golib/leakcheck"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func countGoroutines(before int) int {
+	// wait the exiting goroutines.
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	return runtime.NumGoroutine() - before
+}
+
+func TestFirstResponse(t *testing.T) {
+	queries := make([]func() string, 10)
+	for i := range queries {
+		queries[i] = func() string { return "ok" }
+	}
+
+	before := runtime.NumGoroutine()
+	if FirstResponse(queries) != "ok" {
+		t.Error("unexpected response")
+	}
+	if leaked := countGoroutines(before); leaked != 9 {
+		t.Errorf("expected 9 goroutines leaked, but %v", leaked)
+	}
+
+	verify := leakcheck.VerifyNoLeaks(t)
+	if FirstResponseFixed(queries) != "ok" {
+		t.Error("unexpected response")
+	}
+	verify()
+}
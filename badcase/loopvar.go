@@ -0,0 +1,23 @@
+package badcase
+
+// CaptureLoopVar return n closures, expect the closure i return i.
+//
+// Before go 1.22, or in modules declare an older go version like this one, the loop variable is
+// shared by all iterations, every closure capture the same variable, so they all return n.
+func CaptureLoopVar(n int) []func() int {
+	var funcs []func() int
+	for i := 0; i < n; i++ {
+		funcs = append(funcs, func() int { return i })
+	}
+	return funcs
+}
+
+// CaptureLoopVarFixed copy the loop variable in every iteration.
+func CaptureLoopVarFixed(n int) []func() int {
+	var funcs []func() int
+	for i := 0; i < n; i++ {
+		i := i
+		funcs = append(funcs, func() int { return i })
+	}
+	return funcs
+}
@@ -0,0 +1,18 @@
+package badcase
+
+import (
+	"testing"
+)
+
+func TestCaptureLoopVar(t *testing.T) {
+	for i, f := range CaptureLoopVar(5) {
+		if f() != 5 {
+			t.Errorf("expected closure %v return the last value 5, but %v", i, f())
+		}
+	}
+	for i, f := range CaptureLoopVarFixed(5) {
+		if f() != i {
+			t.Errorf("expected closure %v return %v, but %v", i, i, f())
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package badcase
+
+import (
+	"sync"
+)
+
+// CountWords count the words by workers goroutines.
+//
+// The map is written concurrently without lock, the runtime detect it and crash the process by
+// "fatal error: concurrent map writes", it can't be recovered. The race detector reports it too.
+func CountWords(words []string, workers int) map[string]int {
+	counts := make(map[string]int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(words); i += workers {
+				counts[words[i]]++
+			}
+		}(w)
+	}
+	wg.Wait()
+	return counts
+}
+
+// CountWordsFixed count into a local map in every goroutine, and merge them under a lock.
+func CountWordsFixed(words []string, workers int) map[string]int {
+	counts := make(map[string]int)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			local := make(map[string]int)
+			for i := w; i < len(words); i += workers {
+				local[words[i]]++
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			for word, count := range local {
+				counts[word] += count
+			}
+		}(w)
+	}
+	wg.Wait()
+	return counts
+}
@@ -0,0 +1,25 @@
+package badcase
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestCountWords(t *testing.T) {
+	words := []string{"a", "b", "a", "c", "a", "b"}
+	counts := CountWordsFixed(words, 3)
+	if counts["a"] != 3 || counts["b"] != 2 || counts["c"] != 1 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+
+	// the crash is likely but not guaranteed, more threads make it easier.
+	output, err, _ := runHelper("CountWords", []string{"GOMAXPROCS=8"}, 30*time.Second)
+	if nil == err {
+		t.Skip("concurrent map writes not detected this time")
+	}
+	// the race detector report it before the runtime.
+	if !bytes.Contains(output, []byte("concurrent map")) && !bytes.Contains(output, []byte("DATA RACE")) {
+		t.Errorf("expected concurrent map writes crash, but err: %v\n%s", err, output)
+	}
+}
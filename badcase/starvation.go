@@ -0,0 +1,65 @@
+package badcase
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TightLoop start GOMAXPROCS goroutines spin on a counter for d, and return the count.
+//
+// The loop has no function call, so there is no cooperative preemption point.
+// Before go 1.14, or with GODEBUG=asyncpreemptoff=1, when the caller sleeps, every thread executes
+// a spinning goroutine which never exits, the caller is never scheduled again, and the process hangs.
+func TightLoop(d time.Duration) int64 {
+	var stop int32
+	counts := make([]int64, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i := range counts {
+		wg.Add(1)
+		go func(count *int64) {
+			defer wg.Done()
+			for atomic.LoadInt32(&stop) == 0 {
+				*count++
+			}
+		}(&counts[i])
+	}
+
+	time.Sleep(d)
+	atomic.StoreInt32(&stop, 1)
+	wg.Wait()
+	return sum(counts)
+}
+
+// TightLoopFixed yield the processor periodically, so the caller is scheduled without async preemption.
+func TightLoopFixed(d time.Duration) int64 {
+	var stop int32
+	counts := make([]int64, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i := range counts {
+		wg.Add(1)
+		go func(count *int64) {
+			defer wg.Done()
+			for atomic.LoadInt32(&stop) == 0 {
+				*count++
+				if *count%1024 == 0 {
+					runtime.Gosched()
+				}
+			}
+		}(&counts[i])
+	}
+
+	time.Sleep(d)
+	atomic.StoreInt32(&stop, 1)
+	wg.Wait()
+	return sum(counts)
+}
+
+func sum(counts []int64) int64 {
+	var total int64
+	for _, count := range counts {
+		total += count
+	}
+	return total
+}
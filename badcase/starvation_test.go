@@ -0,0 +1,58 @@
+package badcase
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+const helperEnv = "GOLIB_BADCASE_HELPER"
+
+// TestHelperProcess run the case named by helperEnv in a child process,
+// for the cases which hang or crash the process.
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv(helperEnv) {
+	case "":
+		return
+	case "TightLoop":
+		TightLoop(100 * time.Millisecond)
+	case "TightLoopFixed":
+		TightLoopFixed(100 * time.Millisecond)
+	case "CountWords":
+		words := make([]string, 100000)
+		for i := range words {
+			words[i] = string(rune('a' + i%26))
+		}
+		for i := 0; i < 100; i++ {
+			CountWords(words, 8)
+		}
+	}
+}
+
+// runHelper run the case in a child process with env, it's killed after timeout.
+func runHelper(name string, env []string, timeout time.Duration) (output []byte, err error, timedOut bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(append(os.Environ(), helperEnv+"="+name), env...)
+	output, err = cmd.CombinedOutput()
+	return output, err, nil != ctx.Err()
+}
+
+func TestTightLoop(t *testing.T) {
+	// the async preemption rescue it since go 1.14.
+	if count := TightLoop(50 * time.Millisecond); count <= 0 {
+		t.Errorf("unexpected count %v", count)
+	}
+
+	_, err, timedOut := runHelper("TightLoop", []string{"GODEBUG=asyncpreemptoff=1"}, 2*time.Second)
+	if !timedOut {
+		t.Errorf("expected the process hang without async preemption, but err: %v", err)
+	}
+	output, err, timedOut := runHelper("TightLoopFixed", []string{"GODEBUG=asyncpreemptoff=1"}, 10*time.Second)
+	if timedOut || nil != err {
+		t.Errorf("expected the fixed process exit, but err: %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,46 @@
+package badcase
+
+import (
+	"time"
+)
+
+// ConsumeUntilIdle receive from ch until no message for idle, return the count of messages.
+//
+// time.After create a new timer and channel in every iteration. Before go 1.23 the timer is not
+// collected until it fires, so a busy channel with a long idle accumulates lots of timers in memory.
+func ConsumeUntilIdle(ch <-chan int, idle time.Duration) int {
+	count := 0
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return count
+			}
+			count++
+		case <-time.After(idle):
+			return count
+		}
+	}
+}
+
+// ConsumeUntilIdleFixed reuse one timer, reset it after every message.
+func ConsumeUntilIdleFixed(ch <-chan int, idle time.Duration) int {
+	count := 0
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return count
+			}
+			count++
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(idle)
+		case <-timer.C:
+			return count
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package badcase
+
+import (
+	"testing"
+	"time"
+)
+
+func filledChannel(n int) <-chan int {
+	ch := make(chan int, n)
+	for i := 0; i < n; i++ {
+		ch <- i
+	}
+	close(ch)
+	return ch
+}
+
+func TestConsumeUntilIdle(t *testing.T) {
+	const messages = 1000
+	var count int
+	leaked := testing.AllocsPerRun(10, func() {
+		count = ConsumeUntilIdle(filledChannel(messages), time.Minute)
+	})
+	if count != messages {
+		t.Errorf("unexpected count %v", count)
+	}
+	fixed := testing.AllocsPerRun(10, func() {
+		count = ConsumeUntilIdleFixed(filledChannel(messages), time.Minute)
+	})
+	if count != messages {
+		t.Errorf("unexpected count %v", count)
+	}
+
+	// a timer every message, they are kept in memory for a minute before go 1.23.
+	if leaked < messages || fixed > 10 {
+		t.Errorf("expected a timer every message, but %v allocs, fixed %v allocs", leaked, fixed)
+	}
+
+	if count = ConsumeUntilIdleFixed(make(chan int), 10*time.Millisecond); count != 0 {
+		t.Errorf("expected idle return, but %v", count)
+	}
+}
@@ -0,0 +1,45 @@
+package badcase
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SumAsync add the numbers in goroutines, and wait all of them.
+//
+// wg.Add is called in the new goroutine, Wait may run before any Add, it returns at once
+// and the sum is incomplete. wg.Add must happen before the goroutine starts.
+// go vet reports the Add in a func literal of go statement, but not in a named function like addAsync.
+// addAsync sleep before Add, like the goroutine is not scheduled yet, so the race is always lost.
+func SumAsync(nums []int64) int64 {
+	var total int64
+	var wg sync.WaitGroup
+	for _, num := range nums {
+		go addAsync(&wg, &total, num)
+	}
+	wg.Wait()
+	return atomic.LoadInt64(&total)
+}
+
+func addAsync(wg *sync.WaitGroup, total *int64, num int64) {
+	time.Sleep(time.Millisecond)
+	wg.Add(1)
+	defer wg.Done()
+	atomic.AddInt64(total, num)
+}
+
+// SumAsyncFixed call wg.Add before the goroutine starts.
+func SumAsyncFixed(nums []int64) int64 {
+	var total int64
+	var wg sync.WaitGroup
+	for _, num := range nums {
+		wg.Add(1)
+		go func(num int64) {
+			defer wg.Done()
+			atomic.AddInt64(&total, num)
+		}(num)
+	}
+	wg.Wait()
+	return atomic.LoadInt64(&total)
+}
@@ -0,0 +1,25 @@
+package badcase
+
+import (
+	"testing"
+)
+
+func TestSumAsync(t *testing.T) {
+	nums := make([]int64, 100)
+	for i := range nums {
+		nums[i] = int64(i)
+	}
+
+	incomplete := 0
+	for i := 0; i < 10; i++ {
+		if SumAsync(nums) != 4950 {
+			incomplete++
+		}
+		if total := SumAsyncFixed(nums); total != 4950 {
+			t.Fatalf("unexpected fixed sum %v", total)
+		}
+	}
+	if incomplete == 0 {
+		t.Error("expected Wait return before the goroutines done")
+	}
+}
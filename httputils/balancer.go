@@ -0,0 +1,215 @@
+package httputils
+
+import (
+	"math/rand"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BalancePolicy is the policy to select which resolved IP to dial.
+type BalancePolicy int
+
+const (
+	FirstAvailable    BalancePolicy = iota // dial the resolved IPs in order, it's default.
+	RoundRobin                             // rotate the resolved IPs of every host.
+	Random                                 // select a random IP.
+	LeastOutstanding                       // select the IP with least in flight requests.
+	PowerOfTwoChoices                      // select two random IPs, use the one with less in flight requests.
+)
+
+const (
+	defaultEjectTime = time.Duration(30) * time.Second
+	// the statistics not used for the idle time are removed, unless it's in flight or ejected.
+	balancerIdleTime = time.Duration(5) * time.Minute
+)
+
+// Balancer select the address to dial from DnsResolver results, like outlier detection,
+// the addresses which recently failed to dial will be ejected for a while.
+// When all addresses are ejected, still dial them in order.
+// The address is IP:port, so a refused port not eject the other ports of the same IP, e.g. SRV targets.
+type Balancer struct {
+	Policy    BalancePolicy
+	EjectTime time.Duration // default 30 seconds.
+
+	mutex     sync.Mutex
+	stats     map[string]*addrStats
+	counters  map[string]*roundRobin // round robin counter of every host.
+	lastPrune time.Time
+}
+
+// IPStats is the statistics of a resolved address.
+type IPStats struct {
+	Addr         string // IP:port.
+	IP           string
+	Outstanding  int64 // in flight requests.
+	Requests     int64
+	Dials        int64
+	DialFailures int64
+	EjectedUntil time.Time // zero when not ejected.
+}
+
+type addrStats struct {
+	IPStats
+	lastUsed time.Time
+}
+
+type roundRobin struct {
+	next     uint64
+	lastUsed time.Time
+}
+
+func (b *Balancer) getStats(addr string) *addrStats {
+	if nil == b.stats {
+		b.stats = make(map[string]*addrStats)
+		b.counters = make(map[string]*roundRobin)
+	}
+	stats, found := b.stats[addr]
+	if !found {
+		ip := addr
+		if host, _, err := net.SplitHostPort(addr); nil == err {
+			ip = host
+		}
+		stats = &addrStats{IPStats: IPStats{Addr: addr, IP: ip}}
+		b.stats[addr] = stats
+	}
+	stats.lastUsed = time.Now()
+	return stats
+}
+
+// prune remove the statistics and counters idle for balancerIdleTime, it's run at most once every idle time.
+func (b *Balancer) prune(now time.Time) {
+	if now.Sub(b.lastPrune) < balancerIdleTime {
+		return
+	}
+	b.lastPrune = now
+	for addr, stats := range b.stats {
+		if stats.Outstanding == 0 && !now.Before(stats.EjectedUntil) && now.Sub(stats.lastUsed) >= balancerIdleTime {
+			delete(b.stats, addr)
+		}
+	}
+	for host, counter := range b.counters {
+		if now.Sub(counter.lastUsed) >= balancerIdleTime {
+			delete(b.counters, host)
+		}
+	}
+}
+
+// Order return the dial order of host resolved addresses, the first one is selected by policy,
+// then the other available addresses, the ejected addresses at last.
+// The addrs are IP:port, or IP when the port not matter.
+func (b *Balancer) Order(host string, addrs []string) []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	b.prune(now)
+	available := make([]string, 0, len(addrs))
+	var ejected []string
+	for _, ip := range addrs {
+		if now.Before(b.getStats(ip).EjectedUntil) {
+			ejected = append(ejected, ip)
+		} else {
+			available = append(available, ip)
+		}
+	}
+	if len(available) > 1 {
+		// keep the others in resolved order.
+		selected := b.selectIndex(host, available)
+		ordered := append([]string{available[selected]}, available[:selected]...)
+		available = append(ordered, available[selected+1:]...)
+	}
+	return append(available, ejected...)
+}
+
+func (b *Balancer) selectIndex(host string, ips []string) int {
+	switch b.Policy {
+	case RoundRobin:
+		counter, found := b.counters[host]
+		if !found {
+			counter = &roundRobin{}
+			b.counters[host] = counter
+		}
+		counter.lastUsed = time.Now()
+		counter.next++
+		return int((counter.next - 1) % uint64(len(ips)))
+	case Random:
+		return rand.Intn(len(ips))
+	case LeastOutstanding:
+		selected := 0
+		for i := 1; i < len(ips); i++ {
+			if b.stats[ips[i]].Outstanding < b.stats[ips[selected]].Outstanding {
+				selected = i
+			}
+		}
+		return selected
+	case PowerOfTwoChoices:
+		i := rand.Intn(len(ips))
+		j := rand.Intn(len(ips) - 1)
+		if j >= i {
+			j++
+		}
+		if b.stats[ips[j]].Outstanding < b.stats[ips[i]].Outstanding {
+			return j
+		}
+		return i
+	}
+	return 0
+}
+
+// DialDone record the dial result of the address, eject it when dial failed.
+func (b *Balancer) DialDone(addr string, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	stats := b.getStats(addr)
+	stats.Dials++
+	if nil == err {
+		stats.EjectedUntil = time.Time{}
+		return
+	}
+	ejectTime := b.EjectTime
+	if ejectTime <= 0 {
+		ejectTime = defaultEjectTime
+	}
+	stats.DialFailures++
+	stats.EjectedUntil = time.Now().Add(ejectTime)
+}
+
+func (b *Balancer) begin(addr string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	stats := b.getStats(addr)
+	stats.Outstanding++
+	stats.Requests++
+}
+
+func (b *Balancer) end(addr string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.getStats(addr).Outstanding--
+}
+
+// Stats return the statistics of all dialed addresses, sort by address.
+// The idle addresses are removed after a while, see balancerIdleTime.
+func (b *Balancer) Stats() []IPStats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	stats := make([]IPStats, 0, len(b.stats))
+	for _, s := range b.stats {
+		copied := s.IPStats
+		if !now.Before(copied.EjectedUntil) {
+			copied.EjectedUntil = time.Time{}
+		}
+		stats = append(stats, copied)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Addr < stats[j].Addr
+	})
+	return stats
+}
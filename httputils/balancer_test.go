@@ -0,0 +1,145 @@
+package httputils
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBalancer_Order(t *testing.T) {
+	ips := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+
+	b := &Balancer{Policy: RoundRobin}
+	for i := 0; i < 6; i++ {
+		ordered := b.Order("example.com", ips)
+		if ordered[0] != ips[i%3] || len(ordered) != 3 {
+			t.Errorf("round robin %v: unexpected order %v", i, ordered)
+		}
+	}
+
+	b = &Balancer{Policy: LeastOutstanding}
+	b.begin("10.0.0.1")
+	b.begin("10.0.0.3")
+	if ordered := b.Order("example.com", ips); ordered[0] != "10.0.0.2" || ordered[1] != "10.0.0.1" {
+		t.Errorf("least outstanding: unexpected order %v", ordered)
+	}
+	b.end("10.0.0.1")
+	if ordered := b.Order("example.com", ips); ordered[0] != "10.0.0.1" {
+		t.Errorf("least outstanding: unexpected order %v", ordered)
+	}
+
+	b = &Balancer{Policy: PowerOfTwoChoices}
+	b.begin("10.0.0.1")
+	b.begin("10.0.0.1")
+	b.begin("10.0.0.2")
+	for i := 0; i < 100; i++ {
+		// "10.0.0.1" is the busiest, it's always lose the choice.
+		if ordered := b.Order("example.com", ips); ordered[0] == "10.0.0.1" {
+			t.Fatalf("power of two choices: unexpected order %v", ordered)
+		}
+	}
+
+	b = &Balancer{Policy: Random}
+	counts := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		counts[b.Order("example.com", ips)[0]]++
+	}
+	for _, ip := range ips {
+		if counts[ip] < 800 {
+			t.Errorf("random: unexpected distribution %v", counts)
+		}
+	}
+}
+
+func TestBalancer_DialDone(t *testing.T) {
+	ips := []string{"10.0.0.1", "10.0.0.2"}
+	b := &Balancer{EjectTime: time.Duration(50) * time.Millisecond}
+
+	b.DialDone("10.0.0.1", errors.New("connection refused"))
+	if ordered := b.Order("example.com", ips); ordered[0] != "10.0.0.2" || ordered[1] != "10.0.0.1" {
+		t.Errorf("expected ejected IP at last, but %v", ordered)
+	}
+	stats := b.Stats()
+	if len(stats) != 2 || stats[0].DialFailures != 1 || stats[0].EjectedUntil.IsZero() {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	time.Sleep(time.Duration(60) * time.Millisecond)
+	if ordered := b.Order("example.com", ips); ordered[0] != "10.0.0.1" {
+		t.Errorf("expected ejected IP come back, but %v", ordered)
+	}
+	if stats = b.Stats(); !stats[0].EjectedUntil.IsZero() {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestHttpClient_IPStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	// Nothing listen on 127.0.0.2, dial it will be refused.
+	resolver := &DnsResolver{}
+	resolver.AddHost("api.example.com", "127.0.0.2", "127.0.0.1")
+	client, err := NewHttpClient(&HttpClientConfig{Resolver: resolver, BalancePolicy: RoundRobin})
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://api.example.com:"+port+"/", nil)
+		req.Close = true // dial every time.
+		if _, err = client.Do(req); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	stats := client.IPStats()
+	if len(stats) != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if stats[0].IP != "127.0.0.1" || stats[0].Requests != 3 || stats[0].Outstanding != 0 {
+		t.Errorf("unexpected stats %+v", stats[0])
+	}
+	if stats[1].IP != "127.0.0.2" || stats[1].DialFailures != 1 || stats[1].EjectedUntil.IsZero() {
+		t.Errorf("unexpected stats %+v", stats[1])
+	}
+}
+
+func TestBalancer_Prune(t *testing.T) {
+	b := &Balancer{Policy: RoundRobin}
+	// the refused port not eject the other ports of the same IP.
+	b.DialDone("10.0.0.1:8080", errors.New("connection refused"))
+	addrs := []string{"10.0.0.1:8081", "10.0.0.2:8081"}
+	if ordered := b.Order("b.service:8081", addrs); ordered[0] != "10.0.0.1:8081" {
+		t.Errorf("expected other port not ejected, but %v", ordered)
+	}
+	b.begin("10.0.0.2:8081")
+	if stats := b.Stats(); len(stats) != 3 || stats[0].Addr != "10.0.0.1:8080" || stats[0].IP != "10.0.0.1" {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// idle for a long time.
+	idle := time.Now().Add(-2 * balancerIdleTime)
+	for _, stats := range b.stats {
+		stats.lastUsed = idle
+	}
+	for _, counter := range b.counters {
+		counter.lastUsed = idle
+	}
+	b.lastPrune = idle
+	b.Order("c.service:80", nil)
+	// the in flight and ejected addresses are kept.
+	stats := b.Stats()
+	if len(stats) != 2 || stats[0].Addr != "10.0.0.1:8080" || stats[1].Addr != "10.0.0.2:8081" {
+		t.Errorf("unexpected stats after prune %+v", stats)
+	}
+	if _, found := b.counters["b.service:8081"]; found || len(b.counters) != 0 {
+		t.Errorf("expected idle counters removed, but %v", b.counters)
+	}
+}
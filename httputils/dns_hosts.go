@@ -0,0 +1,195 @@
+package httputils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostTable map host name to IP addresses, like curl --resolve.
+// Support exact name and wildcard name, e.g. "*.example.com" match "api.example.com",
+// but not match "example.com". The longest wildcard win.
+type hostTable struct {
+	exact    map[string][]net.IP
+	wildcard map[string][]net.IP // key is suffix with leading dot, e.g. ".example.com".
+}
+
+func newHostTable() *hostTable {
+	return &hostTable{
+		exact:    make(map[string][]net.IP),
+		wildcard: make(map[string][]net.IP),
+	}
+}
+
+func canonicalHost(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
+
+func (t *hostTable) add(name string, ips []net.IP) {
+	name = canonicalHost(name)
+	if strings.HasPrefix(name, "*.") {
+		t.wildcard[name[1:]] = append(t.wildcard[name[1:]], ips...)
+	} else {
+		t.exact[name] = append(t.exact[name], ips...)
+	}
+}
+
+func (t *hostTable) remove(name string) {
+	name = canonicalHost(name)
+	if strings.HasPrefix(name, "*.") {
+		delete(t.wildcard, name[1:])
+	} else {
+		delete(t.exact, name)
+	}
+}
+
+func (t *hostTable) lookup(host string) ([]net.IP, bool) {
+	if nil == t {
+		return nil, false
+	}
+	host = canonicalHost(host)
+	if ips, found := t.exact[host]; found {
+		return ips, true
+	}
+	suffix := host
+	for {
+		i := strings.IndexByte(suffix, '.')
+		if i < 0 {
+			return nil, false
+		}
+		suffix = suffix[i:]
+		if ips, found := t.wildcard[suffix]; found {
+			return ips, true
+		}
+		suffix = suffix[1:]
+	}
+}
+
+// parseHostsFile parse hosts format, every line is "IP name [name...]", "#" start a comment.
+func parseHostsFile(reader io.Reader) (*hostTable, error) {
+	table := newHostTable()
+	scanner := bufio.NewScanner(reader)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		ip := net.ParseIP(fields[0])
+		if nil == ip || len(fields) < 2 {
+			return nil, fmt.Errorf("hosts: invalid line %v: %q", lineNo, scanner.Text())
+		}
+		for _, name := range fields[1:] {
+			table.add(name, []net.IP{ip})
+		}
+	}
+	return table, scanner.Err()
+}
+
+// AddHost pin the host name to IP addresses, it's take precedence over hosts file and DNS.
+// name can be a wildcard, e.g. "*.example.com".
+func (r *DnsResolver) AddHost(name string, ips ...string) error {
+	r.once.Do(r.init)
+
+	parsed := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		p := net.ParseIP(ip)
+		if nil == p {
+			return fmt.Errorf("hosts: invalid IP address %q for %v", ip, name)
+		}
+		parsed = append(parsed, p)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.overrides.add(name, parsed)
+	return nil
+}
+
+// RemoveHost remove the host name added by AddHost.
+func (r *DnsResolver) RemoveHost(name string) {
+	r.once.Do(r.init)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.overrides.remove(name)
+}
+
+// LoadHostsFile load the hosts format file, replace the previous loaded one.
+// It's take precedence over DNS, but not AddHost.
+func (r *DnsResolver) LoadHostsFile(filename string) error {
+	r.once.Do(r.init)
+
+	file, err := os.Open(filename)
+	if nil != err {
+		return err
+	}
+	defer file.Close()
+
+	table, err := parseHostsFile(file)
+	if nil != err {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.hostsFile = table
+	return nil
+}
+
+// WatchHostsFile load the hosts file, and reload it when it's modify time or size changed.
+// Call the stop func to stop watching. The interval must be positive.
+func (r *DnsResolver) WatchHostsFile(filename string, interval time.Duration) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("hosts: invalid watch interval %v", interval)
+	}
+	fstat, err := os.Stat(filename)
+	if nil != err {
+		return nil, err
+	}
+	if err = r.LoadHostsFile(filename); nil != err {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				current, err := os.Stat(filename)
+				if nil != err || (current.ModTime().Equal(fstat.ModTime()) && current.Size() == fstat.Size()) {
+					continue
+				}
+				// When the file is invalid, keep the previous one and retry next time.
+				if err = r.LoadHostsFile(filename); nil == err {
+					fstat = current
+				}
+			}
+		}
+	}()
+
+	var stopOnce sync.Once
+	return func() { stopOnce.Do(func() { close(done) }) }, nil
+}
+
+// lookupHosts lookup static overrides and hosts file.
+func (r *DnsResolver) lookupHosts(host string) ([]net.IP, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if ips, found := r.overrides.lookup(host); found {
+		return ips, true
+	}
+	return r.hostsFile.lookup(host)
+}
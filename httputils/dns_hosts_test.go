@@ -0,0 +1,139 @@
+package httputils
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHostTable_Lookup(t *testing.T) {
+	table, err := parseHostsFile(strings.NewReader(`
+# comment line
+10.0.0.1   api.example.com API2.Example.com.  # trailing comment
+10.0.0.2   *.example.com
+10.0.0.3   *.internal.example.com
+`))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"api.example.com":         "10.0.0.1",
+		"api2.example.com":        "10.0.0.1",
+		"www.example.com":         "10.0.0.2",
+		"a.b.example.com":         "10.0.0.2",
+		"db.internal.example.com": "10.0.0.3",
+		"example.com":             "",
+		"example.org":             "",
+	}
+	for host, expected := range cases {
+		ips, found := table.lookup(host)
+		if expected == "" {
+			if found {
+				t.Errorf("%v: expected not found, but %v", host, ips)
+			}
+			continue
+		}
+		if !found || len(ips) != 1 || ips[0].String() != expected {
+			t.Errorf("%v: expected %v, but %v", host, expected, ips)
+		}
+	}
+
+	if _, err = parseHostsFile(strings.NewReader("not-an-ip example.com")); nil == err {
+		t.Error("expected invalid line error")
+	}
+}
+
+func TestDnsResolver_AddHost(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hosts")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "hosts")
+	if err = ioutil.WriteFile(filename, []byte("10.0.0.1 api.example.com\n"), 0644); nil != err {
+		t.Fatal(err)
+	}
+
+	resolver := &DnsResolver{TTL: time.Duration(5) * time.Minute}
+	if _, err = resolver.WatchHostsFile(filename, 0); nil == err {
+		t.Error("expected invalid interval error")
+	}
+	stop, err := resolver.WatchHostsFile(filename, time.Duration(10)*time.Millisecond)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	lookup := func(host string) string {
+		addrs, err := resolver.LookupHost(context.Background(), host)
+		if nil != err {
+			return err.Error()
+		}
+		return strings.Join(addrs, ",")
+	}
+
+	if addrs := lookup("api.example.com"); addrs != "10.0.0.1" {
+		t.Errorf("expected hosts file address, but %v", addrs)
+	}
+
+	// Static overrides take precedence over hosts file.
+	if err = resolver.AddHost("*.example.com", "10.0.0.9", "10.0.0.8"); nil != err {
+		t.Fatal(err)
+	}
+	if addrs := lookup("api.example.com"); addrs != "10.0.0.9,10.0.0.8" {
+		t.Errorf("expected override addresses, but %v", addrs)
+	}
+	resolver.RemoveHost("*.example.com")
+
+	// Modify hosts file, watcher should reload it.
+	if err = ioutil.WriteFile(filename, []byte("10.0.0.2 api.example.com\n"), 0644); nil != err {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Duration(2) * time.Second)
+	for lookup("api.example.com") != "10.0.0.2" && time.Now().Before(deadline) {
+		time.Sleep(time.Duration(10) * time.Millisecond)
+	}
+	if addrs := lookup("api.example.com"); addrs != "10.0.0.2" {
+		t.Errorf("expected reloaded address, but %v", addrs)
+	}
+
+	if err = resolver.AddHost("bad.example.com", "not-an-ip"); nil == err {
+		t.Error("expected invalid IP error")
+	}
+}
+
+func TestHttpClient_ResolverOverride(t *testing.T) {
+	// The httptest certificate is valid for example.com.
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + " " + r.TLS.ServerName))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	resolver := &DnsResolver{}
+	resolver.AddHost("example.com", "127.0.0.1")
+
+	trans, err := createTransport(options(&HttpClientConfig{}), resolver, &Balancer{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	trans.TLSClientConfig = &tls.Config{RootCAs: server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}
+	client := &HttpClient{client: &http.Client{Transport: trans}}
+
+	res, err := client.Get(nil, "https://example.com:"+port+"/", nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if expected := "example.com:" + port + " example.com"; res.String() != expected {
+		t.Errorf("expected Host and SNI %q, but %q", expected, res.String())
+	}
+}
@@ -0,0 +1,198 @@
+package httputils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DnsSnapshotVersion is the version of DnsResolver cache snapshot format.
+//
+// The snapshot is a JSON object, e.g.
+//
+//	{
+//	  "version": 1,
+//	  "entries": [
+//	    {"key": "www.example.com", "ips": ["93.184.216.34"], "timestamp": 1571469600000000000},
+//	    {"key": "SRV:_api._tcp.example.com", "cname": "_api._tcp.example.com.",
+//	     "srvs": [{"target": "a.example.com.", "port": 8080, "priority": 10, "weight": 5}], "timestamp": ...},
+//	    {"key": "TXT:example.com", "txts": ["v=spf1 -all"], "timestamp": ...}
+//	  ]
+//	}
+//
+// key is the host name of A/AAAA records, or the name with "SRV:"/"TXT:" prefix,
+// timestamp is the unix nanoseconds of the lookup.
+// Load reject the snapshot of other versions.
+const DnsSnapshotVersion = 1
+
+const defaultSnapshotInterval = time.Duration(1) * time.Minute
+
+type dnsSnapshot struct {
+	Version int                `json:"version"`
+	Entries []dnsSnapshotEntry `json:"entries"`
+}
+
+type dnsSnapshotEntry struct {
+	Key       string           `json:"key"`
+	IPs       []string         `json:"ips,omitempty"`
+	CNAME     string           `json:"cname,omitempty"`
+	SRVs      []dnsSnapshotSRV `json:"srvs,omitempty"`
+	TXTs      []string         `json:"txts,omitempty"`
+	Timestamp int64            `json:"timestamp"`
+}
+
+type dnsSnapshotSRV struct {
+	Target   string `json:"target"`
+	Port     uint16 `json:"port"`
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+}
+
+// Save write all cache entities to writer, see DnsSnapshotVersion for the format.
+func (r *DnsResolver) Save(writer io.Writer) error {
+	r.once.Do(r.init)
+
+	snapshot := dnsSnapshot{Version: DnsSnapshotVersion, Entries: []dnsSnapshotEntry{}}
+	r.mutex.RLock()
+	for key, entity := range r.cache {
+		entry := dnsSnapshotEntry{
+			Key:       key,
+			CNAME:     entity.cname,
+			TXTs:      entity.txts,
+			Timestamp: entity.timestampNano,
+		}
+		for _, ip := range entity.ips {
+			entry.IPs = append(entry.IPs, ip.String())
+		}
+		for _, srv := range entity.srvs {
+			entry.SRVs = append(entry.SRVs, dnsSnapshotSRV{
+				Target:   srv.Target,
+				Port:     srv.Port,
+				Priority: srv.Priority,
+				Weight:   srv.Weight,
+			})
+		}
+		snapshot.Entries = append(snapshot.Entries, entry)
+	}
+	r.mutex.RUnlock()
+
+	return json.NewEncoder(writer).Encode(&snapshot)
+}
+
+// Load read cache entities from reader, the loaded entities are stale, they will be returned
+// at once until MaxStale since loaded, and revalidated in background when lookup them.
+// The entities already in cache are not replaced.
+func (r *DnsResolver) Load(reader io.Reader) error {
+	r.once.Do(r.init)
+
+	var snapshot dnsSnapshot
+	if err := json.NewDecoder(reader).Decode(&snapshot); nil != err {
+		return err
+	}
+	if snapshot.Version != DnsSnapshotVersion {
+		return fmt.Errorf("dns: unsupported snapshot version %v", snapshot.Version)
+	}
+
+	staleUntil := time.Now().Add(r.MaxStale).UnixNano()
+	entities := make(map[string]*cacheEntity, len(snapshot.Entries))
+	for _, entry := range snapshot.Entries {
+		entity := &cacheEntity{
+			cname:         entry.CNAME,
+			txts:          entry.TXTs,
+			timestampNano: entry.Timestamp,
+			stale:         true,
+			staleUntil:    staleUntil,
+		}
+		for _, s := range entry.IPs {
+			ip := net.ParseIP(s)
+			if nil == ip {
+				return fmt.Errorf("dns: invalid IP address %q of %v in snapshot", s, entry.Key)
+			}
+			entity.ips = append(entity.ips, ip)
+		}
+		for _, srv := range entry.SRVs {
+			entity.srvs = append(entity.srvs, &net.SRV{
+				Target:   srv.Target,
+				Port:     srv.Port,
+				Priority: srv.Priority,
+				Weight:   srv.Weight,
+			})
+		}
+		entities[entry.Key] = entity
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for key, entity := range entities {
+		if _, found := r.cache[key]; !found {
+			r.cache[key] = entity
+		}
+	}
+	return nil
+}
+
+// SaveFile save cache entities to file, write a temp file and rename it, so the file is always complete.
+func (r *DnsResolver) SaveFile(filename string) error {
+	file, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if nil != err {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err = r.Save(file); nil != err {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); nil != err {
+		return err
+	}
+	return os.Rename(file.Name(), filename)
+}
+
+// LoadFile load cache entities from file saved by SaveFile.
+func (r *DnsResolver) LoadFile(filename string) error {
+	file, err := os.Open(filename)
+	if nil != err {
+		return err
+	}
+	defer file.Close()
+	return r.Load(file)
+}
+
+// Snapshot save cache entities to file every interval, and save at last when stop.
+// Call the stop func to stop snapshot. Not positive interval default to 1 min.
+func (r *DnsResolver) Snapshot(filename string, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = defaultSnapshotInterval
+	}
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				r.SaveFile(filename)
+				return
+			case <-ticker.C:
+				r.SaveFile(filename)
+			}
+		}
+	}()
+
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			close(done)
+			<-exited
+		})
+	}
+}
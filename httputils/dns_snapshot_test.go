@@ -0,0 +1,145 @@
+package httputils
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDnsResolver_Save(t *testing.T) {
+	upstream := &testUpstream{records: map[string]map[uint16][][]byte{
+		"www.example.com": {
+			dnsTypeA: {{93, 184, 216, 34}},
+		},
+		"_api._tcp.example.com": {
+			dnsTypeSRV: {testSRVData(10, 5, 8080, "a.example.com")},
+			dnsTypeTXT: {append([]byte{5}, "hello"...)},
+		},
+	}}
+	resolver := &DnsResolver{Upstream: upstream}
+	ctx := context.Background()
+	resolver.LookupHost(ctx, "www.example.com")
+	resolver.LookupSRV(ctx, "", "", "_api._tcp.example.com")
+	resolver.LookupTXT(ctx, "_api._tcp.example.com")
+
+	var buf bytes.Buffer
+	if err := resolver.Save(&buf); nil != err {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"version":1`) {
+		t.Errorf("expected version in snapshot, but %v", buf.String())
+	}
+
+	// Warm start, loaded entities return at once, and revalidate in background.
+	upstream = &testUpstream{records: upstream.records}
+	warm := &DnsResolver{Upstream: upstream}
+	if err := warm.Load(bytes.NewReader(buf.Bytes())); nil != err {
+		t.Fatal(err)
+	}
+	addrs, err := warm.LookupHost(ctx, "www.example.com")
+	if nil != err || len(addrs) != 1 || addrs[0] != "93.184.216.34" {
+		t.Errorf("unexpected addrs %v, err: %v", addrs, err)
+	}
+	_, srvs, err := warm.LookupSRV(ctx, "", "", "_api._tcp.example.com")
+	if nil != err || len(srvs) != 1 || srvs[0].Target != "a.example.com." || srvs[0].Port != 8080 {
+		t.Errorf("unexpected srvs %v, err: %v", srvs, err)
+	}
+	txts, err := warm.LookupTXT(ctx, "_api._tcp.example.com")
+	if nil != err || len(txts) != 1 || txts[0] != "hello" {
+		t.Errorf("unexpected txts %v, err: %v", txts, err)
+	}
+
+	deadline := time.Now().Add(time.Duration(2) * time.Second)
+	for time.Now().Before(deadline) {
+		stale := 0
+		warm.mutex.RLock()
+		for _, entity := range warm.cache {
+			if entity.stale {
+				stale++
+			}
+		}
+		warm.mutex.RUnlock()
+		if stale == 0 {
+			break
+		}
+		time.Sleep(time.Duration(10) * time.Millisecond)
+	}
+	// A and AAAA queries for host, SRV and TXT queries.
+	if queries := atomic.LoadInt32(&upstream.queries); queries != 4 {
+		t.Errorf("expected revalidate queries 4, but %v", queries)
+	}
+
+	if err = warm.Load(strings.NewReader(`{"version":2,"entries":[]}`)); nil == err {
+		t.Error("expected unsupported version error")
+	}
+}
+
+func TestDnsResolver_Snapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dns")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "dns.json")
+
+	resolver := &DnsResolver{}
+	resolver.AddHost("api.example.com", "10.0.0.1") // overrides are not in cache.
+	resolver.LookupHost(context.Background(), "127.0.0.1")
+
+	stop := resolver.Snapshot(filename, time.Hour)
+	stop()
+	stop()
+	// not positive interval use the default.
+	resolver.Snapshot(filename, 0)()
+
+	warm := &DnsResolver{}
+	if err = warm.LoadFile(filename); nil != err {
+		t.Fatal(err)
+	}
+	if entities := warm.GetAllEntities(); len(entities) != 1 || !entities[0].stale {
+		t.Errorf("unexpected entities %v", entities)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("expected temp file removed, but %v files", len(files))
+	}
+}
+
+func TestDnsResolver_StaleRevalidateFailed(t *testing.T) {
+	// the upstream answer NXDOMAIN, revalidation always failed.
+	upstream := &testUpstream{}
+	resolver := &DnsResolver{Upstream: upstream, MaxStale: time.Duration(200) * time.Millisecond}
+	snapshot := `{"version":1,"entries":[{"key":"www.example.com","ips":["10.0.0.1"],"timestamp":1}]}`
+	if err := resolver.Load(strings.NewReader(snapshot)); nil != err {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		addrs, err := resolver.LookupHost(ctx, "www.example.com")
+		if nil != err || len(addrs) != 1 || addrs[0] != "10.0.0.1" {
+			t.Fatalf("unexpected stale addrs %v, err: %v", addrs, err)
+		}
+	}
+	time.Sleep(time.Duration(50) * time.Millisecond)
+	// A and AAAA queries of only one revalidation.
+	if queries := atomic.LoadInt32(&upstream.queries); queries != 2 {
+		t.Errorf("expected revalidate queries 2, but %v", queries)
+	}
+
+	// not served after MaxStale, and removed when lookup failed.
+	time.Sleep(time.Duration(200) * time.Millisecond)
+	if addrs, err := resolver.LookupHost(ctx, "www.example.com"); nil == err {
+		t.Errorf("expected lookup error after max stale, but %v", addrs)
+	}
+	if entities := resolver.GetAllEntities(); len(entities) != 0 {
+		t.Errorf("expected expired stale entity removed, but %v", entities)
+	}
+}
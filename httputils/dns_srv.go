@@ -0,0 +1,141 @@
+package httputils
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sort"
+)
+
+// LookupSRV lookup SRV records with cache, arguments and results like net.LookupSRV.
+// When service and proto are empty, lookup name directly, e.g. "_api._tcp.example.com".
+// The addrs order is not meaningful, use OrderSRV to select the target.
+func (r *DnsResolver) LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error) {
+	r.once.Do(r.init)
+
+	target := name
+	if service != "" || proto != "" {
+		target = "_" + service + "._" + proto + "." + name
+	}
+	entry, err := r.queryCache(ctx, "SRV:"+target, func() (interface{}, error) {
+		if nil == r.Upstream {
+			cname, addrs, err := net.DefaultResolver.LookupSRV(context.Background(), "", "", target)
+			if nil != err {
+				return nil, err
+			}
+			return &cacheEntity{cname: cname, srvs: addrs}, nil
+		}
+		return lookupSRV(context.Background(), r.Upstream, target)
+	})
+	if nil != err {
+		return "", nil, err
+	}
+	return entry.cname, append([]*net.SRV{}, entry.srvs...), nil
+}
+
+// LookupTXT lookup TXT records with cache, like net.LookupTXT.
+func (r *DnsResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	r.once.Do(r.init)
+
+	entry, err := r.queryCache(ctx, "TXT:"+name, func() (interface{}, error) {
+		if nil == r.Upstream {
+			txts, err := net.DefaultResolver.LookupTXT(context.Background(), name)
+			if nil != err {
+				return nil, err
+			}
+			return &cacheEntity{txts: txts}, nil
+		}
+		return lookupTXT(context.Background(), r.Upstream, name)
+	})
+	if nil != err {
+		return nil, err
+	}
+	return append([]string{}, entry.txts...), nil
+}
+
+func lookupSRV(ctx context.Context, upstream Upstream, name string) (*cacheEntity, error) {
+	answers, err := exchange(ctx, upstream, name, dnsTypeSRV)
+	if nil != err {
+		return nil, err
+	}
+	entry := &cacheEntity{}
+	for i := range answers {
+		if answers[i].rtype != dnsTypeSRV {
+			continue
+		}
+		srv, err := answers[i].srv()
+		if nil != err {
+			return nil, err
+		}
+		entry.cname = answers[i].name + "."
+		entry.srvs = append(entry.srvs, srv)
+	}
+	if len(entry.srvs) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return entry, nil
+}
+
+func lookupTXT(ctx context.Context, upstream Upstream, name string) (*cacheEntity, error) {
+	answers, err := exchange(ctx, upstream, name, dnsTypeTXT)
+	if nil != err {
+		return nil, err
+	}
+	entry := &cacheEntity{}
+	for i := range answers {
+		if answers[i].rtype != dnsTypeTXT {
+			continue
+		}
+		txt, err := answers[i].txt()
+		if nil != err {
+			return nil, err
+		}
+		entry.txts = append(entry.txts, txt)
+	}
+	if len(entry.txts) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return entry, nil
+}
+
+// OrderSRV return a new slice, sort addrs by priority, and order the records
+// of same priority by weighted random selection.
+// See: https://tools.ietf.org/html/rfc2782
+func OrderSRV(addrs []*net.SRV) []*net.SRV {
+	ordered := append([]*net.SRV{}, addrs...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority < ordered[j].Priority
+	})
+
+	for i := 0; i < len(ordered); {
+		j := i + 1
+		for j < len(ordered) && ordered[j].Priority == ordered[i].Priority {
+			j++
+		}
+		shuffleSRVByWeight(ordered[i:j])
+		i = j
+	}
+	return ordered
+}
+
+func shuffleSRVByWeight(addrs []*net.SRV) {
+	sum := 0
+	for _, addr := range addrs {
+		sum += int(addr.Weight)
+	}
+	for sum > 0 && len(addrs) > 1 {
+		s := 0
+		n := rand.Intn(sum)
+		for i := range addrs {
+			s += int(addrs[i].Weight)
+			if s > n {
+				if i > 0 {
+					addrs[0], addrs[i] = addrs[i], addrs[0]
+				}
+				break
+			}
+		}
+		sum -= int(addrs[0].Weight)
+		addrs = addrs[1:]
+	}
+}
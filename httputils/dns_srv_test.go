@@ -0,0 +1,181 @@
+package httputils
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// testUpstream answer queries with the rdata list of name and type.
+type testUpstream struct {
+	records map[string]map[uint16][][]byte
+	queries int32
+}
+
+func (u *testUpstream) Exchange(ctx context.Context, query []byte) ([]byte, error) {
+	atomic.AddInt32(&u.queries, 1)
+	name, off, err := readDnsName(query, dnsHeaderLen)
+	if nil != err {
+		return nil, err
+	}
+	qtype := binary.BigEndian.Uint16(query[off:])
+	res := append([]byte{}, query[:off+4]...)
+	res[2] |= 0x80 // QR
+
+	rdatas, found := u.records[name]
+	if !found {
+		res[3] |= dnsRcodeNXDomain
+		return res, nil
+	}
+	for _, rdata := range rdatas[qtype] {
+		res = append(res, 0xC0, dnsHeaderLen)
+		res = append(res, byte(qtype>>8), byte(qtype), 0, 1, 0, 0, 0, 60, byte(len(rdata)>>8), byte(len(rdata)))
+		res = append(res, rdata...)
+	}
+	binary.BigEndian.PutUint16(res[6:], uint16(len(rdatas[qtype])))
+	return res, nil
+}
+
+func testSRVData(priority, weight, port uint16, target string) []byte {
+	data := make([]byte, 6)
+	binary.BigEndian.PutUint16(data, priority)
+	binary.BigEndian.PutUint16(data[2:], weight)
+	binary.BigEndian.PutUint16(data[4:], port)
+	data, _ = appendDnsName(data, target)
+	return data
+}
+
+func TestDnsResolver_LookupSRV(t *testing.T) {
+	upstream := &testUpstream{records: map[string]map[uint16][][]byte{
+		"_api._tcp.example.com": {
+			dnsTypeSRV: {testSRVData(20, 0, 8080, "b.example.com"), testSRVData(10, 5, 8080, "a.example.com")},
+			dnsTypeTXT: {append([]byte{5}, "hello"...), append([]byte{2, 'v', '='}, append([]byte{1}, '1')...)},
+		},
+	}}
+	resolver := &DnsResolver{Upstream: upstream}
+
+	cname, addrs, err := resolver.LookupSRV(context.Background(), "api", "tcp", "example.com")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if cname != "_api._tcp.example.com." || len(addrs) != 2 {
+		t.Errorf("unexpected cname %v, addrs %v", cname, addrs)
+	}
+	ordered := OrderSRV(addrs)
+	if ordered[0].Target != "a.example.com." || ordered[0].Port != 8080 || ordered[1].Target != "b.example.com." {
+		t.Errorf("unexpected order %v %v", ordered[0], ordered[1])
+	}
+
+	txts, err := resolver.LookupTXT(context.Background(), "_api._tcp.example.com")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if len(txts) != 2 || txts[0] != "hello" || txts[1] != "v=1" {
+		t.Errorf("unexpected txts %q", txts)
+	}
+
+	// Second lookup hit cache.
+	queries := atomic.LoadInt32(&upstream.queries)
+	resolver.LookupSRV(context.Background(), "", "", "_api._tcp.example.com")
+	resolver.LookupTXT(context.Background(), "_api._tcp.example.com")
+	if atomic.LoadInt32(&upstream.queries) != queries {
+		t.Error("expected lookup hit cache")
+	}
+
+	if _, _, err = resolver.LookupSRV(context.Background(), "", "", "_nx._tcp.example.com"); nil == err {
+		t.Error("expected not found error")
+	}
+}
+
+func TestOrderSRV(t *testing.T) {
+	addrs := []*net.SRV{
+		{Target: "low.", Priority: 20, Weight: 100},
+		{Target: "heavy.", Priority: 10, Weight: 90},
+		{Target: "light.", Priority: 10, Weight: 10},
+	}
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		ordered := OrderSRV(addrs)
+		if ordered[2].Target != "low." {
+			t.Fatalf("expected low priority at last, but %v", ordered[2].Target)
+		}
+		counts[ordered[0].Target]++
+	}
+	// heavy should be selected first about 90% times.
+	if counts["heavy."] < 8500 || counts["heavy."] > 9500 {
+		t.Errorf("unexpected weighted selection %v", counts)
+	}
+}
+
+func TestHttpClient_SRV(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + r.URL.Path))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	livePort, _ := strconv.Atoi(port)
+
+	// Get a closed port.
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	_, port, _ = net.SplitHostPort(listener.Addr().String())
+	deadPort, _ := strconv.Atoi(port)
+	listener.Close()
+
+	resolver := &DnsResolver{Upstream: &testUpstream{records: map[string]map[uint16][][]byte{
+		"_api._tcp.service": {
+			dnsTypeSRV: {testSRVData(10, 0, uint16(deadPort), "dead.service"), testSRVData(20, 0, uint16(livePort), "live.service")},
+		},
+	}}}
+	resolver.AddHost("*.service", "127.0.0.1")
+
+	client, err := NewHttpClient(&HttpClientConfig{Resolver: resolver})
+	if nil != err {
+		t.Fatal(err)
+	}
+	res, err := client.Post(nil, "http+srv://_api._tcp.service/ping", "text/plain", []byte("body"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if expected := "live.service:" + strconv.Itoa(livePort) + "/ping"; res.String() != expected {
+		t.Errorf("expected fail over to %v, but %v", expected, res.String())
+	}
+}
+
+func TestHttpClient_SRVBodyNotReplayable(t *testing.T) {
+	// Get a closed port.
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	deadPort, _ := strconv.Atoi(port)
+	listener.Close()
+
+	resolver := &DnsResolver{Upstream: &testUpstream{records: map[string]map[uint16][][]byte{
+		"_api._tcp.service": {
+			dnsTypeSRV: {
+				testSRVData(0, 0, uint16(deadPort), "."),
+				testSRVData(10, 0, uint16(deadPort), "dead.service"),
+				testSRVData(20, 0, uint16(deadPort), "dead.service"),
+			},
+		},
+	}}}
+	resolver.AddHost("*.service", "127.0.0.1")
+	client, err := NewHttpClient(&HttpClientConfig{Resolver: resolver})
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "http+srv://_api._tcp.service/ping", ioutil.NopCloser(strings.NewReader("body")))
+	if req.GetBody != nil {
+		t.Fatal("expected body not replayable")
+	}
+	res, err := client.Do(req)
+	if nil != res || nil == err || !strings.Contains(err.Error(), "can not be replayed") {
+		t.Errorf("expected not replayable error, but %v, err: %v", res, err)
+	}
+}
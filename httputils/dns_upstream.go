@@ -0,0 +1,217 @@
+package httputils
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	dnsMessageContentType = "application/dns-message"
+
+	defaultDohTimeout = time.Duration(5) * time.Second
+	defaultDotPort    = "853"
+	defaultDotTimeout = time.Duration(5) * time.Second
+)
+
+// Upstream send a DNS query message in wire format and return the raw response message.
+// DnsResolver use system resolver when it's upstream is nil.
+type Upstream interface {
+	Exchange(ctx context.Context, query []byte) ([]byte, error)
+}
+
+// DohUpstream is DNS-over-HTTPS upstream.
+// See: https://tools.ietf.org/html/rfc8484
+//
+// Note: Client dial the DoH server by itself DnsResolver, so the server URL host
+// should be an IP address, or the Client should use system resolver.
+type DohUpstream struct {
+	URL     string        // e.g. https://1.1.1.1/dns-query
+	Method  string        // http.MethodGet or http.MethodPost, default POST.
+	Client  *HttpClient   // default DefaultHttpClient.
+	Timeout time.Duration // default 5 seconds, contain the retries of Client.
+}
+
+func (u *DohUpstream) Exchange(ctx context.Context, query []byte) ([]byte, error) {
+	var req *http.Request
+	var err error
+	if u.Method == http.MethodGet {
+		// GET use base64url without padding dns parameter.
+		dns := base64.RawURLEncoding.EncodeToString(query)
+		sep := "?"
+		if strings.Contains(u.URL, "?") {
+			sep = "&"
+		}
+		req, err = http.NewRequest(http.MethodGet, u.URL+sep+"dns="+dns, nil)
+	} else {
+		req, err = http.NewRequest(http.MethodPost, u.URL, bytes.NewReader(query))
+		if nil == err {
+			req.Header.Set("Content-Type", dnsMessageContentType)
+		}
+	}
+	if nil != err {
+		return nil, err
+	}
+	req.Header.Set("Accept", dnsMessageContentType)
+
+	timeout := u.Timeout
+	if timeout <= 0 {
+		timeout = defaultDohTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := u.Client
+	if nil == client {
+		client = DefaultHttpClient
+	}
+	res, err := client.Do(req.WithContext(ctx))
+	if nil != err {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dns: DoH server %v response status %v", u.URL, res.StatusCode)
+	}
+	return res.Body, nil
+}
+
+// DotUpstream is DNS-over-TLS upstream, every exchange use a new connection.
+// See: https://tools.ietf.org/html/rfc7858
+type DotUpstream struct {
+	Addr      string        // host or host:port, default port 853.
+	TLSConfig *tls.Config   // default verify server name with Addr host.
+	Timeout   time.Duration // default 5 seconds, contain dial, handshake and exchange.
+}
+
+func (u *DotUpstream) Exchange(ctx context.Context, query []byte) ([]byte, error) {
+	if len(query) > 0xFFFF {
+		return nil, errors.New("dns: query message too large")
+	}
+	addr := u.Addr
+	if _, _, err := net.SplitHostPort(addr); nil != err {
+		addr = net.JoinHostPort(addr, defaultDotPort)
+	}
+	host, _, _ := net.SplitHostPort(addr)
+
+	timeout := u.Timeout
+	if timeout <= 0 {
+		timeout = defaultDotTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	rawConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if nil != err {
+		return nil, err
+	}
+	defer rawConn.Close()
+	deadline, _ := ctx.Deadline()
+	rawConn.SetDeadline(deadline)
+
+	config := &tls.Config{}
+	if nil != u.TLSConfig {
+		config = u.TLSConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	conn := tls.Client(rawConn, config)
+	if err = conn.Handshake(); nil != err {
+		return nil, err
+	}
+
+	// Every message prefix two bytes length field.
+	msg := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(msg, uint16(len(query)))
+	copy(msg[2:], query)
+	if _, err = conn.Write(msg); nil != err {
+		return nil, err
+	}
+
+	var length [2]byte
+	if _, err = io.ReadFull(conn, length[:]); nil != err {
+		return nil, err
+	}
+	res := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err = io.ReadFull(conn, res); nil != err {
+		return nil, err
+	}
+	return res, nil
+}
+
+// exchange send a question to upstream and return answers.
+func exchange(ctx context.Context, upstream Upstream, name string, qtype uint16) ([]dnsRR, error) {
+	query, err := buildDnsQuery(name, qtype)
+	if nil != err {
+		return nil, err
+	}
+	res, err := upstream.Exchange(ctx, query)
+	if nil != err {
+		return nil, err
+	}
+	rcode, answers, err := parseDnsResponse(res)
+	if nil != err {
+		return nil, err
+	}
+	switch rcode {
+	case dnsRcodeSuccess:
+		return answers, nil
+	case dnsRcodeNXDomain:
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return nil, &net.DNSError{Err: fmt.Sprintf("server response rcode %v", rcode), Name: name}
+}
+
+// lookupIP query A and AAAA records at the same time, like net.LookupIP.
+func lookupIP(ctx context.Context, upstream Upstream, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); nil != ip {
+		return []net.IP{ip}, nil
+	}
+
+	type result struct {
+		ips []net.IP
+		err error
+	}
+	qtypes := []uint16{dnsTypeA, dnsTypeAAAA}
+	results := make([]chan result, len(qtypes))
+	for i, qtype := range qtypes {
+		results[i] = make(chan result, 1)
+		go func(c chan result, qtype uint16) {
+			answers, err := exchange(ctx, upstream, host, qtype)
+			var ips []net.IP
+			for i := range answers {
+				if ip := answers[i].ip(); nil != ip {
+					ips = append(ips, ip)
+				}
+			}
+			c <- result{ips: ips, err: err}
+		}(results[i], qtype)
+	}
+
+	var ips []net.IP
+	var err error
+	for _, c := range results {
+		res := <-c
+		if nil != res.err && nil == err {
+			err = res.err
+		}
+		ips = append(ips, res.ips...)
+	}
+	if len(ips) > 0 {
+		return ips, nil
+	}
+	if nil == err {
+		err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return nil, err
+}
@@ -0,0 +1,193 @@
+package httputils
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testDnsRecords = map[string][]net.IP{
+	"www.example.com": {net.ParseIP("93.184.216.34"), net.ParseIP("2606:2800:220:1:248:1893:25c8:1946")},
+}
+
+// testDnsAnswer answer the query with testDnsRecords, return nil when the query is invalid.
+// It's called by the server goroutines, so report error by t.Error, not t.Fatal.
+func testDnsAnswer(t *testing.T, query []byte) []byte {
+	name, off, err := readDnsName(query, dnsHeaderLen)
+	if nil != err {
+		t.Error(err)
+		return nil
+	}
+	qtype := binary.BigEndian.Uint16(query[off:])
+	res := append([]byte{}, query[:off+4]...)
+	res[2] |= 0x80 // QR
+
+	ips, found := testDnsRecords[name]
+	if !found {
+		res[3] |= dnsRcodeNXDomain
+		return res
+	}
+	var ancount uint16
+	for _, ip := range ips {
+		data := []byte(ip.To4())
+		if qtype == dnsTypeAAAA {
+			if nil != ip.To4() {
+				continue
+			}
+			data = ip.To16()
+		} else if nil == data {
+			continue
+		}
+		res = append(res, 0xC0, dnsHeaderLen) // pointer to question name.
+		res = append(res, byte(qtype>>8), byte(qtype), 0, 1, 0, 0, 0, 60, 0, byte(len(data)))
+		res = append(res, data...)
+		ancount++
+	}
+	binary.BigEndian.PutUint16(res[6:], ancount)
+	return res
+}
+
+func newTestDohServer(t *testing.T, method string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			t.Errorf("expected method %v, but %v", method, r.Method)
+		}
+		var query []byte
+		var err error
+		if r.Method == http.MethodGet {
+			query, err = base64.RawURLEncoding.DecodeString(r.URL.Query().Get("dns"))
+		} else {
+			if r.Header.Get("Content-Type") != dnsMessageContentType {
+				t.Errorf("unexpected content type %v", r.Header.Get("Content-Type"))
+			}
+			query, err = ioutil.ReadAll(r.Body)
+		}
+		if nil != err {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		res := testDnsAnswer(t, query)
+		if nil == res {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", dnsMessageContentType)
+		w.Write(res)
+	}))
+}
+
+func TestDohUpstream_Exchange(t *testing.T) {
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		server := newTestDohServer(t, method)
+		resolver := &DnsResolver{
+			TTL:      time.Duration(5) * time.Minute,
+			Upstream: &DohUpstream{URL: server.URL + "/dns-query", Method: method},
+		}
+		hosts, err := resolver.LookupHost(context.Background(), "www.example.com")
+		if nil != err {
+			t.Error(err)
+		}
+		if len(hosts) != 1 || hosts[0] != "93.184.216.34" {
+			t.Errorf("%v: unexpected hosts %v", method, hosts)
+		}
+
+		ips, err := lookupIP(context.Background(), resolver.Upstream, "www.example.com")
+		if nil != err || len(ips) != 2 {
+			t.Errorf("%v: unexpected ips %v, err: %v", method, ips, err)
+		}
+
+		_, err = resolver.LookupHost(context.Background(), "nx.example.com")
+		if dnsErr, ok := err.(*net.DNSError); !ok || !dnsErr.IsNotFound {
+			t.Errorf("%v: expected not found error, but %v", method, err)
+		}
+		server.Close()
+	}
+}
+
+func TestDotUpstream_Exchange(t *testing.T) {
+	// Borrow the httptest certificate, it's valid for 127.0.0.1 and example.com.
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certServer.Close()
+	clientConfig := certServer.Client().Transport.(*http.Transport).TLSClientConfig
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: certServer.TLS.Certificates})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if nil != err {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				var length [2]byte
+				if _, err := io.ReadFull(conn, length[:]); nil != err {
+					return
+				}
+				query := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, query); nil != err {
+					return
+				}
+				res := testDnsAnswer(t, query)
+				if nil == res {
+					return
+				}
+				binary.BigEndian.PutUint16(length[:], uint16(len(res)))
+				conn.Write(append(length[:], res...))
+			}(conn)
+		}
+	}()
+
+	resolver := &DnsResolver{
+		TTL: time.Duration(5) * time.Minute,
+		Upstream: &DotUpstream{
+			Addr:      listener.Addr().String(),
+			TLSConfig: &tls.Config{RootCAs: clientConfig.RootCAs},
+		},
+	}
+	hosts, err := resolver.LookupHost(context.Background(), "www.example.com")
+	if nil != err {
+		t.Error(err)
+	}
+	if len(hosts) != 1 || hosts[0] != "93.184.216.34" {
+		t.Errorf("unexpected hosts %v", hosts)
+	}
+
+	// Certificate not match server name.
+	badUpstream := &DotUpstream{
+		Addr:      listener.Addr().String(),
+		TLSConfig: &tls.Config{RootCAs: clientConfig.RootCAs, ServerName: "dns.invalid"},
+	}
+	if _, err = lookupIP(context.Background(), badUpstream, "www.example.com"); nil == err {
+		t.Error("expected certificate verify error")
+	}
+}
+
+func TestDohUpstream_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	upstream := &DohUpstream{URL: server.URL + "/dns-query", Timeout: time.Duration(50) * time.Millisecond}
+	start := time.Now()
+	if _, err := upstream.Exchange(context.Background(), []byte("query")); nil == err {
+		t.Error("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected timeout in 50ms, but %v", elapsed)
+	}
+}
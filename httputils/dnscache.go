@@ -12,29 +12,63 @@ type DnsResolver struct {
 	mutex sync.RWMutex
 	once  sync.Once
 
-	cache map[string]*cacheEntity
-	TTL   time.Duration // default 5 min.
+	cache    map[string]*cacheEntity
+	TTL      time.Duration // default 5 min.
+	MaxStale time.Duration // max duration to serve the stale entities since loaded, default 10 min.
+
+	// Upstream DNS server, e.g. DohUpstream or DotUpstream, default use system resolver.
+	Upstream Upstream
+
+	lookupGroup singleflight.Group
+
+	overrides *hostTable // AddHost static overrides.
+	hostsFile *hostTable // LoadHostsFile loaded hosts.
 }
 
+const (
+	defaultDnsTTL      = time.Duration(5) * time.Minute
+	defaultDnsMaxStale = time.Duration(10) * time.Minute
+	// the min interval to revalidate a stale entity, so the failed revalidation not flood the upstream.
+	staleRevalidateInterval = time.Duration(5) * time.Second
+)
+
 type cacheEntity struct {
 	ips           []net.IP
+	cname         string
+	srvs          []*net.SRV
+	txts          []string
 	timestampNano int64
+	stale         bool  // loaded from disk, need revalidate.
+	staleUntil    int64 // unix nanoseconds, the stale entity is not served after it.
+	revalidateAt  int64 // unix nanoseconds, the next revalidation of stale entity, guarded by mutex.
 }
 
-var lookupGroup singleflight.Group
-
 func (r *DnsResolver) init() {
 	if nil == r.cache {
 		r.cache = make(map[string]*cacheEntity)
 	}
+	if nil == r.overrides {
+		r.overrides = newHostTable()
+	}
+	if 0 == r.TTL {
+		r.TTL = defaultDnsTTL
+	}
+	if 0 == r.MaxStale {
+		r.MaxStale = defaultDnsMaxStale
+	}
 }
 
 func (r *DnsResolver) LookupHost(ctx context.Context, host string) (addrs []string, err error) {
 	r.once.Do(r.init)
 
-	ips, err := r.queryCache(ctx, host)
-	if nil != err {
-		return nil, err
+	// Static overrides and hosts file first, then cache.
+	ips, found := r.lookupHosts(host)
+	if !found {
+		entry, err := r.queryCache(ctx, host, r.lookupFunc(host))
+		if nil != err {
+			return nil, err
+		}
+		ips = entry.ips
 	}
 
 	for _, ip := range ips {
@@ -57,51 +91,90 @@ func (r *DnsResolver) GetAllEntities() []*cacheEntity {
 
 func (r *DnsResolver) lookupFunc(host string) func() (interface{}, error) {
 	return func() (interface{}, error) {
-		return net.LookupIP(host)
+		var ips []net.IP
+		var err error
+		if nil == r.Upstream {
+			ips, err = net.LookupIP(host)
+		} else {
+			// Lookup is shared by all waiting callers, so not bind to anyone's context.
+			ips, err = lookupIP(context.Background(), r.Upstream, host)
+		}
+		if nil != err {
+			return nil, err
+		}
+		return &cacheEntity{ips: ips}, nil
 	}
 }
 
-func (r *DnsResolver) queryCache(ctx context.Context, key string) (ips []net.IP, err error) {
-	r.mutex.RLock()
-	entry, found := r.cache[key]
-	r.mutex.RUnlock()
-
-	if found && time.Now().UnixNano() < entry.timestampNano+r.TTL.Nanoseconds() {
-		return entry.ips, nil
+// queryCache return the cache entity when it's not expired, otherwise call lookup func and update cache.
+// lookup func should return *cacheEntity.
+// The stale entity loaded from disk is returned at once until MaxStale, and revalidated in background
+// at most once every staleRevalidateInterval, the stale entity is removed when it's expired and revalidate failed.
+func (r *DnsResolver) queryCache(ctx context.Context, key string, lookup func() (interface{}, error)) (entry *cacheEntity, err error) {
+	entry, found := r.getCache(key)
+	if found && (!entry.stale || !r.shouldRevalidate(entry)) {
+		return entry, nil
 	}
 
-	c := lookupGroup.DoChan(key, r.lookupFunc(key))
+	c := r.lookupGroup.DoChan(key, func() (interface{}, error) {
+		val, err := lookup()
+		now := time.Now().UnixNano()
+		r.mutex.Lock()
+		if entry, ok := val.(*cacheEntity); ok && nil != entry {
+			// Update cache.
+			entry.timestampNano = now
+			r.cache[key] = entry
+		} else if cached, found := r.cache[key]; found && cached.stale && now >= cached.staleUntil {
+			delete(r.cache, key)
+		}
+		r.mutex.Unlock()
+		return val, err
+	})
+	if found {
+		// The result channel is buffered, not need to receive it.
+		return entry, nil
+	}
 
 	select {
 	case <-ctx.Done():
 		err = ctx.Err()
 		if err == context.DeadlineExceeded {
 			// When query DNS service timeout, we shouldn't waiting query complete.
-			lookupGroup.Forget(key)
+			r.lookupGroup.Forget(key)
 		}
 	case res := <-c:
-		if res.Shared {
-			r.mutex.RLock()
-			entry, found := r.cache[key]
-			r.mutex.RUnlock()
-			if found && time.Now().UnixNano() < entry.timestampNano+r.TTL.Nanoseconds() {
-				return entry.ips, nil
-			}
-		}
-		err := res.Err
+		err = res.Err
 		if nil == err {
-			var ok bool
-			ips, ok = res.Val.([]net.IP)
-			if ok {
-				// Update cache.
-				r.mutex.Lock()
-				r.cache[key] = &cacheEntity{
-					ips:           ips,
-					timestampNano: time.Now().UnixNano(),
-				}
-				r.mutex.Unlock()
-			}
+			entry, _ = res.Val.(*cacheEntity)
 		}
 	}
 	return
 }
+
+// getCache return the cache entity when it's not expired, or it's stale and not exceed MaxStale.
+func (r *DnsResolver) getCache(key string) (*cacheEntity, bool) {
+	r.mutex.RLock()
+	entry, found := r.cache[key]
+	r.mutex.RUnlock()
+	if !found {
+		return nil, false
+	}
+
+	now := time.Now().UnixNano()
+	if entry.stale && now < entry.staleUntil || !entry.stale && now < entry.timestampNano+r.TTL.Nanoseconds() {
+		return entry, true
+	}
+	return nil, false
+}
+
+// shouldRevalidate return true when it's time to revalidate the stale entity, and delay the next one.
+func (r *DnsResolver) shouldRevalidate(entry *cacheEntity) bool {
+	now := time.Now().UnixNano()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if now < entry.revalidateAt {
+		return false
+	}
+	entry.revalidateAt = now + staleRevalidateInterval.Nanoseconds()
+	return true
+}
@@ -2,6 +2,9 @@ package httputils
 
 import (
 	"context"
+	"errors"
+	"github.com/xy1884/golib/leakcheck"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -41,3 +44,36 @@ func TestDnsResolver_GetAllEntities(t *testing.T) {
 		t.Log(entity.ips, entity.timestampNano)
 	}
 }
+
+// blockingUpstream block the queries until released.
+type blockingUpstream struct {
+	release chan struct{}
+	queries int32
+}
+
+func (u *blockingUpstream) Exchange(ctx context.Context, query []byte) ([]byte, error) {
+	atomic.AddInt32(&u.queries, 1)
+	<-u.release
+	return nil, errors.New("released")
+}
+
+func TestDnsResolver_LookupTimeout(t *testing.T) {
+	defer leakcheck.VerifyNoLeaks(t)()
+
+	upstream := &blockingUpstream{release: make(chan struct{})}
+	resolver := &DnsResolver{Upstream: upstream}
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := resolver.LookupHost(ctx, "blocked.example")
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Errorf("expected deadline exceeded, but %v", err)
+		}
+	}
+	// the timeout query is forgot, so the next lookup query again.
+	if queries := atomic.LoadInt32(&upstream.queries); queries != 4 {
+		t.Errorf("expected 4 queries of A and AAAA, but %v", queries)
+	}
+	// the forgot queries exit after upstream returned.
+	close(upstream.release)
+}
@@ -0,0 +1,201 @@
+package httputils
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+)
+
+// Minimal DNS message codec (RFC 1035), only what the encrypted upstreams need:
+// build a single question query and parse the answer section of the response.
+
+const (
+	dnsTypeA    uint16 = 1
+	dnsTypeTXT  uint16 = 16
+	dnsTypeAAAA uint16 = 28
+	dnsTypeSRV  uint16 = 33
+
+	dnsClassINET uint16 = 1
+
+	dnsHeaderLen = 12
+	dnsMaxName   = 255
+	dnsMaxLabel  = 63
+
+	dnsRcodeSuccess  = 0
+	dnsRcodeNXDomain = 3
+)
+
+var errDnsMalformed = errors.New("dns: malformed message")
+
+type dnsRR struct {
+	name  string
+	rtype uint16
+	class uint16
+	ttl   uint32
+	data  []byte
+
+	// the whole message, rdata may contain compressed names point to it.
+	msg  []byte
+	doff int
+}
+
+// buildDnsQuery build a recursive query with single question.
+// The ID always is 0, see: https://tools.ietf.org/html/rfc8484#section-4.1
+func buildDnsQuery(name string, qtype uint16) ([]byte, error) {
+	msg := make([]byte, dnsHeaderLen, dnsHeaderLen+len(name)+6)
+	binary.BigEndian.PutUint16(msg[2:], 0x0100) // RD
+	binary.BigEndian.PutUint16(msg[4:], 1)      // QDCOUNT
+
+	msg, err := appendDnsName(msg, name)
+	if nil != err {
+		return nil, err
+	}
+	msg = append(msg, byte(qtype>>8), byte(qtype), byte(dnsClassINET>>8), byte(dnsClassINET))
+	return msg, nil
+}
+
+func appendDnsName(msg []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if len(name) == 0 {
+		return append(msg, 0), nil
+	}
+	if len(name)+2 > dnsMaxName {
+		return nil, errors.New("dns: name too long: " + name)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if len(label) == 0 || len(label) > dnsMaxLabel {
+			return nil, errors.New("dns: invalid name: " + name)
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	return append(msg, 0), nil
+}
+
+// readDnsName read a (possibly compressed) name at off, return the name and the offset after it.
+func readDnsName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+	for hops := 0; ; hops++ {
+		if off >= len(msg) || hops > dnsMaxName {
+			return "", 0, errDnsMalformed
+		}
+		c := int(msg[off])
+		switch c & 0xC0 {
+		case 0x00:
+			if c == 0 {
+				if next < 0 {
+					next = off + 1
+				}
+				return strings.Join(labels, "."), next, nil
+			}
+			if off+1+c > len(msg) {
+				return "", 0, errDnsMalformed
+			}
+			labels = append(labels, string(msg[off+1:off+1+c]))
+			off += 1 + c
+		case 0xC0:
+			if off+1 >= len(msg) {
+				return "", 0, errDnsMalformed
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = (c&0x3F)<<8 | int(msg[off+1])
+		default:
+			return "", 0, errDnsMalformed
+		}
+	}
+}
+
+// parseDnsResponse parse the header and answer section of a response message.
+func parseDnsResponse(msg []byte) (rcode int, answers []dnsRR, err error) {
+	if len(msg) < dnsHeaderLen {
+		return 0, nil, errDnsMalformed
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&0x8000 == 0 {
+		return 0, nil, errors.New("dns: message is not a response")
+	}
+	rcode = int(flags & 0x000F)
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+
+	off := dnsHeaderLen
+	for i := 0; i < qdcount; i++ {
+		if _, off, err = readDnsName(msg, off); nil != err {
+			return 0, nil, err
+		}
+		off += 4
+	}
+
+	for i := 0; i < ancount; i++ {
+		var rr dnsRR
+		if rr.name, off, err = readDnsName(msg, off); nil != err {
+			return 0, nil, err
+		}
+		if off+10 > len(msg) {
+			return 0, nil, errDnsMalformed
+		}
+		rr.rtype = binary.BigEndian.Uint16(msg[off:])
+		rr.class = binary.BigEndian.Uint16(msg[off+2:])
+		rr.ttl = binary.BigEndian.Uint32(msg[off+4:])
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+rdlen > len(msg) {
+			return 0, nil, errDnsMalformed
+		}
+		rr.data = msg[off : off+rdlen]
+		rr.msg = msg
+		rr.doff = off
+		off += rdlen
+		answers = append(answers, rr)
+	}
+	return rcode, answers, nil
+}
+
+func (rr *dnsRR) ip() net.IP {
+	switch {
+	case rr.rtype == dnsTypeA && len(rr.data) == net.IPv4len:
+		return net.IPv4(rr.data[0], rr.data[1], rr.data[2], rr.data[3])
+	case rr.rtype == dnsTypeAAAA && len(rr.data) == net.IPv6len:
+		ip := make(net.IP, net.IPv6len)
+		copy(ip, rr.data)
+		return ip
+	}
+	return nil
+}
+
+func (rr *dnsRR) srv() (*net.SRV, error) {
+	if rr.rtype != dnsTypeSRV || len(rr.data) < 7 {
+		return nil, errDnsMalformed
+	}
+	target, _, err := readDnsName(rr.msg, rr.doff+6)
+	if nil != err {
+		return nil, err
+	}
+	return &net.SRV{
+		Target:   target + ".",
+		Priority: binary.BigEndian.Uint16(rr.data),
+		Weight:   binary.BigEndian.Uint16(rr.data[2:]),
+		Port:     binary.BigEndian.Uint16(rr.data[4:]),
+	}, nil
+}
+
+// txt join all character-strings of the record, like net.LookupTXT.
+func (rr *dnsRR) txt() (string, error) {
+	if rr.rtype != dnsTypeTXT {
+		return "", errDnsMalformed
+	}
+	var txt []byte
+	for off := 0; off < len(rr.data); {
+		n := int(rr.data[off])
+		if off+1+n > len(rr.data) {
+			return "", errDnsMalformed
+		}
+		txt = append(txt, rr.data[off+1:off+1+n]...)
+		off += 1 + n
+	}
+	return string(txt), nil
+}
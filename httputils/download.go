@@ -0,0 +1,80 @@
+package httputils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RateLimiter limit the bytes rate, e.g. *sendfile.Limiter, share it to limit the total bandwidth.
+type RateLimiter interface {
+	// WaitN block until n bytes are allowed or ctx is done.
+	WaitN(ctx context.Context, n int) error
+}
+
+// DownloadOptions is the options of Download.
+type DownloadOptions struct {
+	Header  map[string]string
+	Limiter RateLimiter
+
+	// Progress is called after every chunk written, total is -1 when the Content-Length is unknown.
+	Progress func(received, total int64)
+}
+
+const downloadChunkSize = 32 * 1024
+
+// Download stream the response body of GET url to w, without buffering the whole body in memory.
+// The request is retried and failed over like Do, e.g. http+srv:// URL, but the body is never retried.
+// It return the bytes written, the status code not 2xx is an error.
+func (c *HttpClient) Download(ctx context.Context, url string, w io.Writer, options *DownloadOptions) (int64, error) {
+	if nil == options {
+		options = &DownloadOptions{}
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if nil != err {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range options.Header {
+		req.Header.Set(k, v)
+	}
+
+	// same as Do, with the retries, SRV failover and balancer stats.
+	rawRes, done, err := c.send(req)
+	if nil != err {
+		return 0, err
+	}
+	defer done()
+	defer rawRes.Body.Close()
+	if rawRes.StatusCode < 200 || rawRes.StatusCode >= 300 {
+		return 0, fmt.Errorf("download %v failed, status: %v", url, rawRes.Status)
+	}
+
+	var received int64
+	buf := make([]byte, downloadChunkSize)
+	for {
+		n, err := rawRes.Body.Read(buf)
+		if n > 0 {
+			if nil != options.Limiter {
+				if err := options.Limiter.WaitN(ctx, n); nil != err {
+					return received, err
+				}
+			}
+			m, err := w.Write(buf[:n])
+			received += int64(m)
+			if nil != err {
+				return received, err
+			}
+			if nil != options.Progress {
+				options.Progress(received, rawRes.ContentLength)
+			}
+		}
+		if err == io.EOF {
+			return received, nil
+		}
+		if nil != err {
+			return received, err
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package httputils
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testLimiter count the limited bytes.
+type testLimiter struct {
+	bytes int64
+}
+
+func (l *testLimiter) WaitN(ctx context.Context, n int) error {
+	atomic.AddInt64(&l.bytes, int64(n))
+	return ctx.Err()
+}
+
+func TestHttpClient_Download(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 20000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/artifact" || r.Header.Get("X-Token") != "secret" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, "artifact", time.Time{}, bytes.NewReader(data))
+	}))
+	defer server.Close()
+
+	client, err := NewHttpClient(&HttpClientConfig{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	limiter := &testLimiter{}
+	var last, total int64
+	var buf bytes.Buffer
+	n, err := client.Download(context.Background(), server.URL+"/artifact", &buf, &DownloadOptions{
+		Header:  map[string]string{"X-Token": "secret"},
+		Limiter: limiter,
+		Progress: func(received, size int64) {
+			last, total = received, size
+		},
+	})
+	if nil != err || n != int64(len(data)) || !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("unexpected downloaded %v, err: %v", n, err)
+	}
+	if limiter.bytes != int64(len(data)) || last != int64(len(data)) || total != int64(len(data)) {
+		t.Errorf("unexpected limited %v, progress %v/%v", limiter.bytes, last, total)
+	}
+
+	if _, err = client.Download(context.Background(), server.URL+"/missing", &buf, nil); nil == err {
+		t.Error("expected status error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = client.Download(ctx, server.URL+"/artifact", &buf, &DownloadOptions{Limiter: limiter}); nil == err {
+		t.Error("expected canceled error")
+	}
+}
+
+func TestHttpClient_DownloadSRV(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	livePort, _ := strconv.Atoi(port)
+
+	resolver := &DnsResolver{Upstream: &testUpstream{records: map[string]map[uint16][][]byte{
+		"_artifact._tcp.service": {dnsTypeSRV: {testSRVData(10, 0, uint16(livePort), "live.service")}},
+	}}}
+	resolver.AddHost("*.service", "127.0.0.1")
+	client, err := NewHttpClient(&HttpClientConfig{
+		Resolver:           resolver,
+		BalancePolicy:      RoundRobin,
+		MaxRetry:           3,
+		RetryWaitTimeMs:    1,
+		MaxRetryWaitTimeMs: 10,
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	client.RetryConditions = []RetryConditionFunc{func(res *http.Response, err error) bool {
+		return nil != err || res.StatusCode == http.StatusServiceUnavailable
+	}}
+
+	var buf bytes.Buffer
+	n, err := client.Download(context.Background(), "http+srv://_artifact._tcp.service/artifact", &buf, nil)
+	if nil != err || n != int64(len(data)) || !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("unexpected downloaded %v, err: %v", n, err)
+	}
+	if atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("expected retried once, but %v requests", requests)
+	}
+	stats := client.IPStats()
+	if len(stats) != 1 || stats[0].IP != "127.0.0.1" || stats[0].Requests == 0 || stats[0].Outstanding != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
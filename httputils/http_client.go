@@ -10,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/cookiejar"
+	"net/http/httptrace"
 	netUrl "net/url"
 	"strings"
 	"time"
@@ -20,6 +21,9 @@ import (
 // 2. Retry Exponential Backoff And Jitter Strategy. See: https://aws.amazon.com/cn/blogs/architecture/exponential-backoff-and-jitter/
 // 3. Multi-Goroutine Download.
 // 4. Allow Custom Max Redirects.
+// 5. SRV Service Discovery, e.g. http+srv://_api._tcp.example.com/path
+// 6. Client-side Load Balancing Across Resolved IPs.
+// 7. Streaming Download With Rate Limit And Progress.
 type HttpClient struct {
 	MaxRetry         int
 	RetryWaitTime    time.Duration
@@ -28,7 +32,9 @@ type HttpClient struct {
 	AllowRedirect     bool
 	MaxAllowRedirects int
 
-	client *http.Client
+	client   *http.Client
+	resolver *DnsResolver
+	balancer *Balancer
 
 	// Custom parse response HTTP Retry-After header.
 	// See: https://www.w3.org/Protocols/rfc2616/rfc2616-sec14.html
@@ -55,10 +61,19 @@ func NewHttpClient(config *HttpClientConfig) (*HttpClient, error) {
 		MaxRetry:         config.MaxRetry,
 		RetryWaitTime:    time.Duration(config.RetryWaitTimeMs) * time.Millisecond,
 		MaxRetryWaitTime: time.Duration(config.MaxRetryWaitTimeMs) * time.Millisecond,
+
+		resolver: config.Resolver,
+		balancer: &Balancer{
+			Policy:    config.BalancePolicy,
+			EjectTime: time.Duration(config.EjectTimeMs) * time.Millisecond,
+		},
+	}
+	if nil == httpClient.resolver {
+		httpClient.resolver = &DnsResolver{} // use local DNS cache.
 	}
 
 	// 3. init transport
-	trans, err := createTransport(config)
+	trans, err := createTransport(config, httpClient.resolver, httpClient.balancer)
 	if nil != err {
 		return nil, err
 	}
@@ -87,7 +102,7 @@ func NewHttpClient(config *HttpClientConfig) (*HttpClient, error) {
 	return httpClient, nil
 }
 
-func createTransport(config *HttpClientConfig) (trans *http.Transport, err error) {
+func createTransport(config *HttpClientConfig, resolver *DnsResolver, balancer *Balancer) (trans *http.Transport, err error) {
 	var proxyUrl *netUrl.URL
 	var proxyHeader http.Header
 	if config.ProxyUrl != "" {
@@ -102,8 +117,8 @@ func createTransport(config *HttpClientConfig) (trans *http.Transport, err error
 	}
 
 	trans = &http.Transport{
+		// Only dial the resolved IP, transport still use the original host name for TLS SNI and Host header.
 		DialContext: func(ctx context.Context, network string, addr string) (conn net.Conn, err error) {
-			resolver := &DnsResolver{} // use local DNS cache.
 			host, port, err := net.SplitHostPort(addr)
 			if err != nil {
 				return nil, err
@@ -112,12 +127,24 @@ func createTransport(config *HttpClientConfig) (trans *http.Transport, err error
 			if err != nil {
 				return nil, err
 			}
-			for _, ip := range ips {
+			if len(ips) == 0 {
+				return nil, &net.DNSError{Err: "no IPv4 address", Name: host}
+			}
+			ipAddrs := make([]string, len(ips))
+			for i, ip := range ips {
+				ipAddrs[i] = net.JoinHostPort(ip, port)
+			}
+			for _, ipAddr := range balancer.Order(addr, ipAddrs) {
 				var dialer net.Dialer
 				dialer.Timeout = time.Duration(config.TimeoutMs) * time.Millisecond
 				dialer.KeepAlive = time.Duration(config.KeepAliveMs) * time.Millisecond
 				dialer.DualStack = true
-				conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+				conn, err = dialer.DialContext(ctx, network, ipAddr)
+				if nil != ctx.Err() {
+					// canceled by caller, it's not the IP fault.
+					break
+				}
+				balancer.DialDone(ipAddr, err)
 				if err == nil {
 					break
 				}
@@ -135,6 +162,14 @@ func createTransport(config *HttpClientConfig) (trans *http.Transport, err error
 	return trans, nil
 }
 
+// IPStats return the statistics of all dialed IP:port addresses.
+func (c *HttpClient) IPStats() []IPStats {
+	if nil == c.balancer {
+		return nil
+	}
+	return c.balancer.Stats()
+}
+
 func (c *HttpClient) Cookies(u *netUrl.URL) []*http.Cookie {
 	return c.client.Jar.Cookies(u)
 }
@@ -186,8 +221,47 @@ func (c *HttpClient) Post(header map[string]string, url, contentType string, bod
 }
 
 func (c *HttpClient) Do(req *http.Request) (*Response, error) {
-	var err error
-	var rawRes *http.Response
+	rawRes, done, err := c.send(req)
+	if nil != err {
+		return nil, err
+	}
+	defer done()
+	return readResponse(rawRes)
+}
+
+// send dispatch the SRV request, return the raw response, the caller must close the body,
+// and call done after the body is read.
+func (c *HttpClient) send(req *http.Request) (rawRes *http.Response, done func(), err error) {
+	if strings.HasSuffix(req.URL.Scheme, srvSchemeSuffix) {
+		return c.sendSRV(req)
+	}
+	return c.roundTrip(req)
+}
+
+// roundTrip do request with the balancer stats, retries and cookies.
+// The caller must close the body, and call done after the body is read.
+func (c *HttpClient) roundTrip(req *http.Request) (rawRes *http.Response, done func(), err error) {
+	done = func() {}
+	// count the in flight requests of every IP:port.
+	if nil != c.balancer {
+		var addr string
+		trace := &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				if addr != "" {
+					// previous try of retry.
+					c.balancer.end(addr)
+				}
+				addr = info.Conn.RemoteAddr().String()
+				c.balancer.begin(addr)
+			},
+		}
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		done = func() {
+			if addr != "" {
+				c.balancer.end(addr)
+			}
+		}
+	}
 
 	// 1. do request
 	if 0 == c.MaxRetry {
@@ -206,15 +280,20 @@ func (c *HttpClient) Do(req *http.Request) (*Response, error) {
 	}
 
 	if nil != err {
-		return nil, err
+		done()
+		return nil, nil, err
 	}
 
 	// 2. process cookies
-	if len(rawRes.Cookies()) > 0 {
+	if len(rawRes.Cookies()) > 0 && nil != c.client.Jar {
 		c.client.Jar.SetCookies(req.URL, rawRes.Cookies())
 	}
+	return rawRes, done, nil
+}
 
-	// 3. read response body
+// readResponse read and close the body of raw response.
+func readResponse(rawRes *http.Response) (*Response, error) {
+	var err error
 	rawBody := rawRes.Body
 	defer rawRes.Body.Close()
 	if strings.EqualFold(rawRes.Header.Get("Content-Encoding"), "gzip") && rawRes.ContentLength > 0 {
@@ -1,7 +1,10 @@
 package httputils
 
 import (
+	"github.com/xy1884/golib/leakcheck"
 	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -89,3 +92,36 @@ func TestRetryConditions(t *testing.T) {
 	t.Log(res.StatusCode)
 	t.Log(res.Header)
 }
+
+func TestBackoff_NoLeaks(t *testing.T) {
+	defer leakcheck.VerifyNoLeaks(t)()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	transport := &http.Transport{}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport}
+
+	var statusCode int
+	err := Backoff(func() (*http.Response, error) {
+		res, err := client.Get(server.URL)
+		if nil != err {
+			return nil, err
+		}
+		res.Body.Close()
+		statusCode = res.StatusCode
+		return res, nil
+	}, MaxRetries(5), RetryWaitTime(time.Millisecond), MaxRetryWaitTime(10*time.Millisecond),
+		RetryConditions([]RetryConditionFunc{func(res *http.Response, err error) bool {
+			return nil != err || res.StatusCode == http.StatusServiceUnavailable
+		}}))
+	if nil != err || statusCode != http.StatusOK || atomic.LoadInt32(&requests) != 3 {
+		t.Errorf("unexpected status %v after %v requests, err: %v", statusCode, requests, err)
+	}
+}
@@ -0,0 +1,63 @@
+package httputils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// srvSchemeSuffix mark the URL host is a SRV record name, e.g. http+srv://_api._tcp.example.com/path
+const srvSchemeSuffix = "+srv"
+
+var defaultDnsResolver = &DnsResolver{}
+
+// sendSRV lookup the SRV records of the URL host, request the targets in RFC 2782 order,
+// fail over to the next target when request failed.
+func (c *HttpClient) sendSRV(req *http.Request) (rawRes *http.Response, done func(), err error) {
+	resolver := c.resolver
+	if nil == resolver {
+		resolver = defaultDnsResolver
+	}
+	_, addrs, err := resolver.LookupSRV(req.Context(), "", "", req.URL.Hostname())
+	if nil != err {
+		return nil, nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, nil, errors.New("no SRV targets for " + req.URL.Hostname())
+	}
+
+	scheme := strings.TrimSuffix(req.URL.Scheme, srvSchemeSuffix)
+	sent := false // the body is consumed after sent.
+	for _, addr := range OrderSRV(addrs) {
+		// "." target means the service is decidedly not available.
+		// See: https://tools.ietf.org/html/rfc2782
+		if addr.Target == "." {
+			continue
+		}
+
+		targetReq := req.Clone(req.Context())
+		if sent && nil != req.Body && http.NoBody != req.Body {
+			if nil == req.GetBody {
+				return nil, nil, fmt.Errorf("request body can not be replayed for SRV failover: %v", err)
+			}
+			if targetReq.Body, err = req.GetBody(); nil != err {
+				return nil, nil, err
+			}
+		}
+		targetReq.URL.Scheme = scheme
+		targetReq.URL.Host = net.JoinHostPort(strings.TrimSuffix(addr.Target, "."), strconv.Itoa(int(addr.Port)))
+		targetReq.Host = ""
+
+		sent = true
+		if rawRes, done, err = c.roundTrip(targetReq); nil == err {
+			return rawRes, done, nil
+		}
+	}
+	if nil == err {
+		err = errors.New("no available SRV targets for " + req.URL.Hostname())
+	}
+	return nil, nil, err
+}
@@ -19,6 +19,11 @@ type HttpClientConfig struct {
 	ProxyUrl    string // support http, https, socks proxy.
 	ProxyUname  string
 	ProxyPasswd string
+
+	BalancePolicy BalancePolicy // default dial the resolved IPs in order.
+	EjectTimeMs   int64         // default eject the IP failed to dial 30 seconds.
+
+	Resolver *DnsResolver // default use a new DnsResolver, 5 min TTL. also use to lookup SRV records.
 }
 
 var DefaultHttpClientConfig = &HttpClientConfig{
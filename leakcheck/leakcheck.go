@@ -0,0 +1,220 @@
+// Package leakcheck verify the goroutines started by a test are exited when the test is done.
+// It only parse the output of runtime.Stack, so it works on every go version and needs no runtime hook.
+//
+//	func TestXxx(t *testing.T) {
+//		defer leakcheck.VerifyNoLeaks(t)()
+//		...
+//	}
+package leakcheck
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// T is the subset of testing.TB used by VerifyNoLeaks.
+type T interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Goroutine is a goroutine parsed from the output of runtime.Stack.
+type Goroutine struct {
+	ID        int64
+	State     string // e.g. "chan receive", "IO wait, 2 minutes".
+	Top       string // function of the top frame, e.g. "net/http.(*persistConn).readLoop".
+	CreatedBy string // function started the goroutine, empty of the main goroutine.
+	Stack     string // the whole stack of the goroutine.
+}
+
+// Option configure VerifyNoLeaks.
+type Option func(*options)
+
+type options struct {
+	grace   time.Duration
+	ignores []func(g *Goroutine) bool
+}
+
+const defaultGracePeriod = time.Second
+
+// The known background goroutines of the standard library and testing package.
+var defaultIgnoredTops = []string{
+	"testing.(*T).Run",
+	"testing.(*T).Parallel",
+	"testing.runTests",
+	"testing.tRunner.func1",
+	"os/signal.signal_recv",
+	"os/signal.loop",
+	"runtime.ensureSigM",
+	"runtime/trace.Start.func1",
+}
+
+// GracePeriod set the max duration to wait the goroutines exiting, default 1 second.
+func GracePeriod(d time.Duration) Option {
+	return func(o *options) {
+		o.grace = d
+	}
+}
+
+// IgnoreTopFunction ignore the goroutines whose top frame is the function fn,
+// fn is the full name as printed in stack, e.g. "internal/poll.runtime_pollWait".
+func IgnoreTopFunction(fn string) Option {
+	return Ignore(func(g *Goroutine) bool {
+		return g.Top == fn
+	})
+}
+
+// IgnoreCreatedBy ignore the goroutines started by the function fn.
+func IgnoreCreatedBy(fn string) Option {
+	return Ignore(func(g *Goroutine) bool {
+		return g.CreatedBy == fn
+	})
+}
+
+// Ignore ignore the goroutines matched by the filter.
+func Ignore(filter func(g *Goroutine) bool) Option {
+	return func(o *options) {
+		o.ignores = append(o.ignores, filter)
+	}
+}
+
+// VerifyNoLeaks snapshot the running goroutines, and return a function should be called when the test is done.
+// The function wait the new goroutines exiting until the grace period, then report the left ones by t.Errorf.
+func VerifyNoLeaks(t T, opts ...Option) func() {
+	o := &options{grace: defaultGracePeriod}
+	for _, opt := range opts {
+		opt(o)
+	}
+	for _, top := range defaultIgnoredTops {
+		IgnoreTopFunction(top)(o)
+	}
+
+	before := make(map[int64]bool)
+	for _, g := range Goroutines() {
+		before[g.ID] = true
+	}
+
+	return func() {
+		t.Helper()
+		leaked := waitLeaks(before, o)
+		if len(leaked) == 0 {
+			return
+		}
+		stacks := make([]string, len(leaked))
+		for i, g := range leaked {
+			stacks[i] = g.Stack
+		}
+		t.Errorf("found %v leaked goroutines after %v:\n\n%v", len(leaked), o.grace, strings.Join(stacks, "\n\n"))
+	}
+}
+
+// waitLeaks retry until no new goroutine is running or the grace period is over, return the left ones.
+func waitLeaks(before map[int64]bool, o *options) []*Goroutine {
+	deadline := time.Now().Add(o.grace)
+	delay := time.Millisecond
+	for {
+		leaked := findLeaks(before, o)
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(delay)
+		if delay < 100*time.Millisecond {
+			delay *= 2
+		}
+	}
+}
+
+func findLeaks(before map[int64]bool, o *options) []*Goroutine {
+	var leaked []*Goroutine
+	goroutines := Goroutines()
+	// The first one is the current goroutine.
+	for _, g := range goroutines[1:] {
+		if before[g.ID] || ignored(g, o.ignores) {
+			continue
+		}
+		leaked = append(leaked, g)
+	}
+	return leaked
+}
+
+func ignored(g *Goroutine, ignores []func(g *Goroutine) bool) bool {
+	for _, ignore := range ignores {
+		if ignore(g) {
+			return true
+		}
+	}
+	return false
+}
+
+// Goroutines return all the running goroutines, the current goroutine is the first one.
+func Goroutines() []*Goroutine {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return parseStacks(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// parseStacks parse the output of runtime.Stack, the stacks are separated by an empty line:
+//
+//	goroutine 7 [chan receive]:
+//	main.worker(0xc000012345)
+//		/path/to/main.go:12 +0x2a
+//	created by main.main in goroutine 1
+//		/path/to/main.go:8 +0x5c
+func parseStacks(data []byte) []*Goroutine {
+	var goroutines []*Goroutine
+	for _, block := range bytes.Split(bytes.TrimSpace(data), []byte("\n\n")) {
+		if g := parseGoroutine(string(block)); nil != g {
+			goroutines = append(goroutines, g)
+		}
+	}
+	return goroutines
+}
+
+func parseGoroutine(stack string) *Goroutine {
+	lines := strings.Split(stack, "\n")
+	// goroutine 7 [chan receive]:
+	header := lines[0]
+	if !strings.HasPrefix(header, "goroutine ") {
+		return nil
+	}
+	fields := strings.Fields(header)
+	id, err := strconv.ParseInt(fields[1], 10, 64)
+	if nil != err {
+		return nil
+	}
+	g := &Goroutine{ID: id, Stack: stack}
+	if start, end := strings.Index(header, "["), strings.LastIndex(header, "]"); start >= 0 && end > start {
+		g.State = header[start+1 : end]
+	}
+
+	if len(lines) > 1 && !strings.HasPrefix(lines[1], "\t") {
+		g.Top = frameFunction(lines[1])
+	}
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "created by ") {
+			createdBy := strings.TrimPrefix(line, "created by ")
+			// since go 1.21: created by main.main in goroutine 1
+			if i := strings.Index(createdBy, " in goroutine "); i >= 0 {
+				createdBy = createdBy[:i]
+			}
+			g.CreatedBy = createdBy
+		}
+	}
+	return g
+}
+
+// frameFunction return the function of a frame line, e.g. "net/http.(*conn).serve(0xc0001a2000, ...)".
+func frameFunction(line string) string {
+	if i := strings.LastIndex(line, "("); i > 0 {
+		return line[:i]
+	}
+	return line
+}
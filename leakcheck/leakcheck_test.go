@@ -0,0 +1,88 @@
+package leakcheck
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testT record the errors instead of failing the test.
+type testT struct {
+	errors []string
+}
+
+func (t *testT) Helper() {}
+
+func (t *testT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func blockOn(c chan struct{}) {
+	<-c
+}
+
+func TestParseStacks(t *testing.T) {
+	stacks := `goroutine 1 [running]:
+main.main()
+	/tmp/main.go:10 +0x1d
+
+goroutine 7 [chan receive, 2 minutes]:
+net/http.(*persistConn).readLoop(0xc000123456)
+	/usr/local/go/src/net/http/transport.go:2200 +0x2a
+created by net/http.(*Transport).dialConn in goroutine 6
+	/usr/local/go/src/net/http/transport.go:1800 +0x5c
+
+goroutine 8 [IO wait]:
+`
+	goroutines := parseStacks([]byte(stacks))
+	if len(goroutines) != 3 {
+		t.Fatalf("unexpected %v goroutines", len(goroutines))
+	}
+	if g := goroutines[0]; g.ID != 1 || g.State != "running" || g.Top != "main.main" || g.CreatedBy != "" {
+		t.Errorf("unexpected goroutine %+v", g)
+	}
+	g := goroutines[1]
+	if g.ID != 7 || g.State != "chan receive, 2 minutes" || g.Top != "net/http.(*persistConn).readLoop" ||
+		g.CreatedBy != "net/http.(*Transport).dialConn" {
+		t.Errorf("unexpected goroutine %+v", g)
+	}
+	if g := goroutines[2]; g.ID != 8 || g.Top != "" {
+		t.Errorf("unexpected goroutine %+v", g)
+	}
+}
+
+func TestVerifyNoLeaks(t *testing.T) {
+	defer VerifyNoLeaks(t)()
+
+	// exited in the grace period.
+	fake := &testT{}
+	verify := VerifyNoLeaks(fake)
+	go time.Sleep(50 * time.Millisecond)
+	verify()
+	if len(fake.errors) != 0 {
+		t.Errorf("unexpected errors %v", fake.errors)
+	}
+
+	c := make(chan struct{})
+	defer close(c)
+	verify = VerifyNoLeaks(fake, GracePeriod(50*time.Millisecond))
+	go blockOn(c)
+	verify()
+	if len(fake.errors) != 1 || !strings.Contains(fake.errors[0], "leakcheck.blockOn") {
+		t.Fatalf("expected blockOn leaked, but %v", fake.errors)
+	}
+
+	fake = &testT{}
+	verify = VerifyNoLeaks(fake, GracePeriod(50*time.Millisecond),
+		IgnoreTopFunction("github.com/xy1884/golib/leakcheck.blockOn"))
+	go blockOn(c)
+	verify()
+	verify = VerifyNoLeaks(fake, GracePeriod(50*time.Millisecond),
+		IgnoreCreatedBy("github.com/xy1884/golib/leakcheck.TestVerifyNoLeaks"))
+	go blockOn(c)
+	verify()
+	if len(fake.errors) != 0 {
+		t.Errorf("expected ignored, but %v", fake.errors)
+	}
+}
@@ -0,0 +1,108 @@
+package mmap
+
+import (
+	"os"
+)
+
+// Advice tell the kernel how the mapping will be accessed, see madvise(2).
+type Advice int
+
+const (
+	Normal     Advice = iota // no special treatment.
+	Sequential               // read ahead aggressively, free pages soon after accessed.
+	Random                   // not read ahead.
+	WillNeed                 // read ahead the pages now.
+	DontNeed                 // free the pages, private mapping modifications are lost.
+	HugePage                 // use transparent huge pages, linux only.
+)
+
+// prefaultSink keep the prefault reads not be optimized out.
+var prefaultSink byte
+
+// Advise give the access pattern advice of the whole mapping to kernel.
+func (f *File) Advise(advice Advice) error {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	if nil == f.data {
+		return os.ErrClosed
+	}
+	if len(f.mapped) == 0 {
+		return nil
+	}
+	return madvise(f.mapped, advice)
+}
+
+// Lock lock the mapping pages in memory, so they are never swapped out, see mlock(2).
+func (f *File) Lock() error {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	if nil == f.data {
+		return os.ErrClosed
+	}
+	if len(f.mapped) == 0 {
+		return nil
+	}
+	return mlock(f.mapped)
+}
+
+// Unlock unlock the pages locked by Lock.
+func (f *File) Unlock() error {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	if nil == f.data {
+		return os.ErrClosed
+	}
+	if len(f.mapped) == 0 {
+		return nil
+	}
+	return munlock(f.mapped)
+}
+
+// Resident return the fraction of the mapping pages resident in memory, see mincore(2).
+func (f *File) Resident() (float64, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	if nil == f.data {
+		return 0, os.ErrClosed
+	}
+	if len(f.mapped) == 0 {
+		return 1, nil
+	}
+
+	pageSize := os.Getpagesize()
+	vec := make([]byte, (len(f.mapped)+pageSize-1)/pageSize)
+	if err := mincore(f.mapped, vec); nil != err {
+		return 0, err
+	}
+
+	resident := 0
+	for _, v := range vec {
+		// the least significant bit is set when the page is resident.
+		resident += int(v & 1)
+	}
+	return float64(resident) / float64(len(vec)), nil
+}
+
+// Prefault read the mapping pages into memory before latency sensitive reads,
+// it's advise WillNeed and touch every page.
+func (f *File) Prefault() error {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	if nil == f.data {
+		return os.ErrClosed
+	}
+	if len(f.mapped) == 0 {
+		return nil
+	}
+
+	// the advice is only a hint, ignore it's error.
+	madvise(f.mapped, WillNeed)
+
+	return f.guard(func() {
+		var sum byte
+		for i := 0; i < len(f.mapped); i += os.Getpagesize() {
+			sum += f.mapped[i]
+		}
+		prefaultSink = sum
+	})
+}
@@ -0,0 +1,27 @@
+package mmap
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func madvise(b []byte, advice Advice) error {
+	var flag int
+	switch advice {
+	case Normal:
+		flag = syscall.MADV_NORMAL
+	case Sequential:
+		flag = syscall.MADV_SEQUENTIAL
+	case Random:
+		flag = syscall.MADV_RANDOM
+	case WillNeed:
+		flag = syscall.MADV_WILLNEED
+	case DontNeed:
+		flag = syscall.MADV_DONTNEED
+	case HugePage:
+		flag = syscall.MADV_HUGEPAGE
+	default:
+		return fmt.Errorf("mmap: invalid advice %v", advice)
+	}
+	return syscall.Madvise(b, flag)
+}
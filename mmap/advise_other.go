@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package mmap
+
+import (
+	"errors"
+)
+
+func madvise(b []byte, advice Advice) error {
+	return errors.New("mmap: madvise is not supported on this platform")
+}
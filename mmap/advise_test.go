@@ -0,0 +1,48 @@
+package mmap
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFile_Advise(t *testing.T) {
+	filename, _, cleanup := createTestFile(t, 4*os.Getpagesize())
+	defer cleanup()
+
+	file, err := Open(filename)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	for _, advice := range []Advice{Sequential, Random, WillNeed, Normal} {
+		if err = file.Advise(advice); nil != err {
+			t.Errorf("advice %v: %v", advice, err)
+		}
+	}
+	if err = file.Advise(Advice(100)); nil == err {
+		t.Error("expected invalid advice error")
+	}
+
+	if err = file.Prefault(); nil != err {
+		t.Error(err)
+	}
+	resident, err := file.Resident()
+	if nil != err || resident != 1 {
+		t.Errorf("expected all pages resident after prefault, but %v, err: %v", resident, err)
+	}
+
+	if err = file.Lock(); nil != err {
+		t.Error(err)
+	}
+	if err = file.Unlock(); nil != err {
+		t.Error(err)
+	}
+
+	file.Close()
+	if _, err = file.Resident(); err != os.ErrClosed {
+		t.Errorf("expected closed error, but %v", err)
+	}
+	if err = file.Prefault(); err != os.ErrClosed {
+		t.Errorf("expected closed error, but %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package mmap
+
+//go:generate go run gen_array.go
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// nativeEndian is the byte order of the running machine.
+var nativeEndian binary.ByteOrder
+
+func init() {
+	x := uint16(1)
+	if (*[2]byte)(unsafe.Pointer(&x))[0] == 1 {
+		nativeEndian = binary.LittleEndian
+	} else {
+		nativeEndian = binary.BigEndian
+	}
+}
+
+// arrayBytes return the mapped bytes of f as an array of elements of size,
+// it check the mapping length is a multiple of size and the start is aligned to size.
+func arrayBytes(f *File, size int, writable bool) ([]byte, error) {
+	if writable && !f.Writable() {
+		return nil, ErrReadOnly
+	}
+	data := f.Bytes()
+	if nil == data {
+		return nil, fmt.Errorf("mmap: mapping is closed")
+	}
+	if len(data)%size != 0 {
+		return nil, fmt.Errorf("mmap: mapping length %v is not a multiple of element size %v", len(data), size)
+	}
+	if len(data) > 0 && uintptr(unsafe.Pointer(&data[0]))%uintptr(size) != 0 {
+		return nil, fmt.Errorf("mmap: mapping is not aligned to element size %v", size)
+	}
+	return data, nil
+}
+
+// castSlice point the slice header at slice to data, the length is len(data)/size.
+func castSlice(data []byte, size int, slice unsafe.Pointer) {
+	if len(data) == 0 {
+		return
+	}
+	h := (*reflect.SliceHeader)(slice)
+	h.Data = uintptr(unsafe.Pointer(&data[0]))
+	h.Len = len(data) / size
+	h.Cap = h.Len
+}
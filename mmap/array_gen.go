@@ -0,0 +1,611 @@
+// Code generated by gen_array.go; DO NOT EDIT.
+
+package mmap
+
+import (
+	"encoding/binary"
+	"math"
+	"unsafe"
+)
+
+var _ = math.Float64bits
+
+// Int16Slice is a read-only view of the mapping as an array of int16 in the byte order.
+// It's only valid until the File is closed or truncated.
+type Int16Slice struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// NewInt16Slice view the mapping as an array of int16 in order,
+// the mapping length must be a multiple of 2 and aligned.
+func NewInt16Slice(f *File, order binary.ByteOrder) (*Int16Slice, error) {
+	data, err := arrayBytes(f, 2, false)
+	if nil != err {
+		return nil, err
+	}
+	return &Int16Slice{data: data, order: order}, nil
+}
+
+// Len return the count of elements.
+func (s *Int16Slice) Len() int {
+	return len(s.data) / 2
+}
+
+// At return the element at index i, it panics when i is out of range.
+func (s *Int16Slice) At(i int) int16 {
+	u := s.order.Uint16(s.data[i*2:])
+	return int16(u)
+}
+
+// CopyTo copy the elements from index i to dst, return the count copied.
+func (s *Int16Slice) CopyTo(dst []int16, i int) int {
+	n := s.Len() - i
+	if n > len(dst) {
+		n = len(dst)
+	}
+	if n < 0 {
+		n = 0
+	}
+	for j := 0; j < n; j++ {
+		dst[j] = s.At(i + j)
+	}
+	return n
+}
+
+// Native return the mapping as []int16 without copy when the byte order is native, otherwise nil.
+// The returned slice has the same lifetime as the mapping, never retain it,
+// and never modify it when the view is read only.
+func (s *Int16Slice) Native() []int16 {
+	if s.order != nativeEndian {
+		return nil
+	}
+	var native []int16
+	castSlice(s.data, 2, unsafe.Pointer(&native))
+	return native
+}
+
+// Int16SliceRW is a read-write view of the mapping as an array of int16 in the byte order.
+type Int16SliceRW struct {
+	Int16Slice
+}
+
+// NewInt16SliceRW view the writable mapping as an array of int16 in order,
+// the mapping length must be a multiple of 2 and aligned.
+func NewInt16SliceRW(f *File, order binary.ByteOrder) (*Int16SliceRW, error) {
+	data, err := arrayBytes(f, 2, true)
+	if nil != err {
+		return nil, err
+	}
+	return &Int16SliceRW{Int16Slice{data: data, order: order}}, nil
+}
+
+// Set set the element at index i, it panics when i is out of range.
+func (s *Int16SliceRW) Set(i int, v int16) {
+	s.order.PutUint16(s.data[i*2:], uint16(v))
+}
+
+// Uint16Slice is a read-only view of the mapping as an array of uint16 in the byte order.
+// It's only valid until the File is closed or truncated.
+type Uint16Slice struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// NewUint16Slice view the mapping as an array of uint16 in order,
+// the mapping length must be a multiple of 2 and aligned.
+func NewUint16Slice(f *File, order binary.ByteOrder) (*Uint16Slice, error) {
+	data, err := arrayBytes(f, 2, false)
+	if nil != err {
+		return nil, err
+	}
+	return &Uint16Slice{data: data, order: order}, nil
+}
+
+// Len return the count of elements.
+func (s *Uint16Slice) Len() int {
+	return len(s.data) / 2
+}
+
+// At return the element at index i, it panics when i is out of range.
+func (s *Uint16Slice) At(i int) uint16 {
+	u := s.order.Uint16(s.data[i*2:])
+	return u
+}
+
+// CopyTo copy the elements from index i to dst, return the count copied.
+func (s *Uint16Slice) CopyTo(dst []uint16, i int) int {
+	n := s.Len() - i
+	if n > len(dst) {
+		n = len(dst)
+	}
+	if n < 0 {
+		n = 0
+	}
+	for j := 0; j < n; j++ {
+		dst[j] = s.At(i + j)
+	}
+	return n
+}
+
+// Native return the mapping as []uint16 without copy when the byte order is native, otherwise nil.
+// The returned slice has the same lifetime as the mapping, never retain it,
+// and never modify it when the view is read only.
+func (s *Uint16Slice) Native() []uint16 {
+	if s.order != nativeEndian {
+		return nil
+	}
+	var native []uint16
+	castSlice(s.data, 2, unsafe.Pointer(&native))
+	return native
+}
+
+// Uint16SliceRW is a read-write view of the mapping as an array of uint16 in the byte order.
+type Uint16SliceRW struct {
+	Uint16Slice
+}
+
+// NewUint16SliceRW view the writable mapping as an array of uint16 in order,
+// the mapping length must be a multiple of 2 and aligned.
+func NewUint16SliceRW(f *File, order binary.ByteOrder) (*Uint16SliceRW, error) {
+	data, err := arrayBytes(f, 2, true)
+	if nil != err {
+		return nil, err
+	}
+	return &Uint16SliceRW{Uint16Slice{data: data, order: order}}, nil
+}
+
+// Set set the element at index i, it panics when i is out of range.
+func (s *Uint16SliceRW) Set(i int, v uint16) {
+	s.order.PutUint16(s.data[i*2:], v)
+}
+
+// Int32Slice is a read-only view of the mapping as an array of int32 in the byte order.
+// It's only valid until the File is closed or truncated.
+type Int32Slice struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// NewInt32Slice view the mapping as an array of int32 in order,
+// the mapping length must be a multiple of 4 and aligned.
+func NewInt32Slice(f *File, order binary.ByteOrder) (*Int32Slice, error) {
+	data, err := arrayBytes(f, 4, false)
+	if nil != err {
+		return nil, err
+	}
+	return &Int32Slice{data: data, order: order}, nil
+}
+
+// Len return the count of elements.
+func (s *Int32Slice) Len() int {
+	return len(s.data) / 4
+}
+
+// At return the element at index i, it panics when i is out of range.
+func (s *Int32Slice) At(i int) int32 {
+	u := s.order.Uint32(s.data[i*4:])
+	return int32(u)
+}
+
+// CopyTo copy the elements from index i to dst, return the count copied.
+func (s *Int32Slice) CopyTo(dst []int32, i int) int {
+	n := s.Len() - i
+	if n > len(dst) {
+		n = len(dst)
+	}
+	if n < 0 {
+		n = 0
+	}
+	for j := 0; j < n; j++ {
+		dst[j] = s.At(i + j)
+	}
+	return n
+}
+
+// Native return the mapping as []int32 without copy when the byte order is native, otherwise nil.
+// The returned slice has the same lifetime as the mapping, never retain it,
+// and never modify it when the view is read only.
+func (s *Int32Slice) Native() []int32 {
+	if s.order != nativeEndian {
+		return nil
+	}
+	var native []int32
+	castSlice(s.data, 4, unsafe.Pointer(&native))
+	return native
+}
+
+// Int32SliceRW is a read-write view of the mapping as an array of int32 in the byte order.
+type Int32SliceRW struct {
+	Int32Slice
+}
+
+// NewInt32SliceRW view the writable mapping as an array of int32 in order,
+// the mapping length must be a multiple of 4 and aligned.
+func NewInt32SliceRW(f *File, order binary.ByteOrder) (*Int32SliceRW, error) {
+	data, err := arrayBytes(f, 4, true)
+	if nil != err {
+		return nil, err
+	}
+	return &Int32SliceRW{Int32Slice{data: data, order: order}}, nil
+}
+
+// Set set the element at index i, it panics when i is out of range.
+func (s *Int32SliceRW) Set(i int, v int32) {
+	s.order.PutUint32(s.data[i*4:], uint32(v))
+}
+
+// Uint32Slice is a read-only view of the mapping as an array of uint32 in the byte order.
+// It's only valid until the File is closed or truncated.
+type Uint32Slice struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// NewUint32Slice view the mapping as an array of uint32 in order,
+// the mapping length must be a multiple of 4 and aligned.
+func NewUint32Slice(f *File, order binary.ByteOrder) (*Uint32Slice, error) {
+	data, err := arrayBytes(f, 4, false)
+	if nil != err {
+		return nil, err
+	}
+	return &Uint32Slice{data: data, order: order}, nil
+}
+
+// Len return the count of elements.
+func (s *Uint32Slice) Len() int {
+	return len(s.data) / 4
+}
+
+// At return the element at index i, it panics when i is out of range.
+func (s *Uint32Slice) At(i int) uint32 {
+	u := s.order.Uint32(s.data[i*4:])
+	return u
+}
+
+// CopyTo copy the elements from index i to dst, return the count copied.
+func (s *Uint32Slice) CopyTo(dst []uint32, i int) int {
+	n := s.Len() - i
+	if n > len(dst) {
+		n = len(dst)
+	}
+	if n < 0 {
+		n = 0
+	}
+	for j := 0; j < n; j++ {
+		dst[j] = s.At(i + j)
+	}
+	return n
+}
+
+// Native return the mapping as []uint32 without copy when the byte order is native, otherwise nil.
+// The returned slice has the same lifetime as the mapping, never retain it,
+// and never modify it when the view is read only.
+func (s *Uint32Slice) Native() []uint32 {
+	if s.order != nativeEndian {
+		return nil
+	}
+	var native []uint32
+	castSlice(s.data, 4, unsafe.Pointer(&native))
+	return native
+}
+
+// Uint32SliceRW is a read-write view of the mapping as an array of uint32 in the byte order.
+type Uint32SliceRW struct {
+	Uint32Slice
+}
+
+// NewUint32SliceRW view the writable mapping as an array of uint32 in order,
+// the mapping length must be a multiple of 4 and aligned.
+func NewUint32SliceRW(f *File, order binary.ByteOrder) (*Uint32SliceRW, error) {
+	data, err := arrayBytes(f, 4, true)
+	if nil != err {
+		return nil, err
+	}
+	return &Uint32SliceRW{Uint32Slice{data: data, order: order}}, nil
+}
+
+// Set set the element at index i, it panics when i is out of range.
+func (s *Uint32SliceRW) Set(i int, v uint32) {
+	s.order.PutUint32(s.data[i*4:], v)
+}
+
+// Int64Slice is a read-only view of the mapping as an array of int64 in the byte order.
+// It's only valid until the File is closed or truncated.
+type Int64Slice struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// NewInt64Slice view the mapping as an array of int64 in order,
+// the mapping length must be a multiple of 8 and aligned.
+func NewInt64Slice(f *File, order binary.ByteOrder) (*Int64Slice, error) {
+	data, err := arrayBytes(f, 8, false)
+	if nil != err {
+		return nil, err
+	}
+	return &Int64Slice{data: data, order: order}, nil
+}
+
+// Len return the count of elements.
+func (s *Int64Slice) Len() int {
+	return len(s.data) / 8
+}
+
+// At return the element at index i, it panics when i is out of range.
+func (s *Int64Slice) At(i int) int64 {
+	u := s.order.Uint64(s.data[i*8:])
+	return int64(u)
+}
+
+// CopyTo copy the elements from index i to dst, return the count copied.
+func (s *Int64Slice) CopyTo(dst []int64, i int) int {
+	n := s.Len() - i
+	if n > len(dst) {
+		n = len(dst)
+	}
+	if n < 0 {
+		n = 0
+	}
+	for j := 0; j < n; j++ {
+		dst[j] = s.At(i + j)
+	}
+	return n
+}
+
+// Native return the mapping as []int64 without copy when the byte order is native, otherwise nil.
+// The returned slice has the same lifetime as the mapping, never retain it,
+// and never modify it when the view is read only.
+func (s *Int64Slice) Native() []int64 {
+	if s.order != nativeEndian {
+		return nil
+	}
+	var native []int64
+	castSlice(s.data, 8, unsafe.Pointer(&native))
+	return native
+}
+
+// Int64SliceRW is a read-write view of the mapping as an array of int64 in the byte order.
+type Int64SliceRW struct {
+	Int64Slice
+}
+
+// NewInt64SliceRW view the writable mapping as an array of int64 in order,
+// the mapping length must be a multiple of 8 and aligned.
+func NewInt64SliceRW(f *File, order binary.ByteOrder) (*Int64SliceRW, error) {
+	data, err := arrayBytes(f, 8, true)
+	if nil != err {
+		return nil, err
+	}
+	return &Int64SliceRW{Int64Slice{data: data, order: order}}, nil
+}
+
+// Set set the element at index i, it panics when i is out of range.
+func (s *Int64SliceRW) Set(i int, v int64) {
+	s.order.PutUint64(s.data[i*8:], uint64(v))
+}
+
+// Uint64Slice is a read-only view of the mapping as an array of uint64 in the byte order.
+// It's only valid until the File is closed or truncated.
+type Uint64Slice struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// NewUint64Slice view the mapping as an array of uint64 in order,
+// the mapping length must be a multiple of 8 and aligned.
+func NewUint64Slice(f *File, order binary.ByteOrder) (*Uint64Slice, error) {
+	data, err := arrayBytes(f, 8, false)
+	if nil != err {
+		return nil, err
+	}
+	return &Uint64Slice{data: data, order: order}, nil
+}
+
+// Len return the count of elements.
+func (s *Uint64Slice) Len() int {
+	return len(s.data) / 8
+}
+
+// At return the element at index i, it panics when i is out of range.
+func (s *Uint64Slice) At(i int) uint64 {
+	u := s.order.Uint64(s.data[i*8:])
+	return u
+}
+
+// CopyTo copy the elements from index i to dst, return the count copied.
+func (s *Uint64Slice) CopyTo(dst []uint64, i int) int {
+	n := s.Len() - i
+	if n > len(dst) {
+		n = len(dst)
+	}
+	if n < 0 {
+		n = 0
+	}
+	for j := 0; j < n; j++ {
+		dst[j] = s.At(i + j)
+	}
+	return n
+}
+
+// Native return the mapping as []uint64 without copy when the byte order is native, otherwise nil.
+// The returned slice has the same lifetime as the mapping, never retain it,
+// and never modify it when the view is read only.
+func (s *Uint64Slice) Native() []uint64 {
+	if s.order != nativeEndian {
+		return nil
+	}
+	var native []uint64
+	castSlice(s.data, 8, unsafe.Pointer(&native))
+	return native
+}
+
+// Uint64SliceRW is a read-write view of the mapping as an array of uint64 in the byte order.
+type Uint64SliceRW struct {
+	Uint64Slice
+}
+
+// NewUint64SliceRW view the writable mapping as an array of uint64 in order,
+// the mapping length must be a multiple of 8 and aligned.
+func NewUint64SliceRW(f *File, order binary.ByteOrder) (*Uint64SliceRW, error) {
+	data, err := arrayBytes(f, 8, true)
+	if nil != err {
+		return nil, err
+	}
+	return &Uint64SliceRW{Uint64Slice{data: data, order: order}}, nil
+}
+
+// Set set the element at index i, it panics when i is out of range.
+func (s *Uint64SliceRW) Set(i int, v uint64) {
+	s.order.PutUint64(s.data[i*8:], v)
+}
+
+// Float32Slice is a read-only view of the mapping as an array of float32 in the byte order.
+// It's only valid until the File is closed or truncated.
+type Float32Slice struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// NewFloat32Slice view the mapping as an array of float32 in order,
+// the mapping length must be a multiple of 4 and aligned.
+func NewFloat32Slice(f *File, order binary.ByteOrder) (*Float32Slice, error) {
+	data, err := arrayBytes(f, 4, false)
+	if nil != err {
+		return nil, err
+	}
+	return &Float32Slice{data: data, order: order}, nil
+}
+
+// Len return the count of elements.
+func (s *Float32Slice) Len() int {
+	return len(s.data) / 4
+}
+
+// At return the element at index i, it panics when i is out of range.
+func (s *Float32Slice) At(i int) float32 {
+	u := s.order.Uint32(s.data[i*4:])
+	return math.Float32frombits(u)
+}
+
+// CopyTo copy the elements from index i to dst, return the count copied.
+func (s *Float32Slice) CopyTo(dst []float32, i int) int {
+	n := s.Len() - i
+	if n > len(dst) {
+		n = len(dst)
+	}
+	if n < 0 {
+		n = 0
+	}
+	for j := 0; j < n; j++ {
+		dst[j] = s.At(i + j)
+	}
+	return n
+}
+
+// Native return the mapping as []float32 without copy when the byte order is native, otherwise nil.
+// The returned slice has the same lifetime as the mapping, never retain it,
+// and never modify it when the view is read only.
+func (s *Float32Slice) Native() []float32 {
+	if s.order != nativeEndian {
+		return nil
+	}
+	var native []float32
+	castSlice(s.data, 4, unsafe.Pointer(&native))
+	return native
+}
+
+// Float32SliceRW is a read-write view of the mapping as an array of float32 in the byte order.
+type Float32SliceRW struct {
+	Float32Slice
+}
+
+// NewFloat32SliceRW view the writable mapping as an array of float32 in order,
+// the mapping length must be a multiple of 4 and aligned.
+func NewFloat32SliceRW(f *File, order binary.ByteOrder) (*Float32SliceRW, error) {
+	data, err := arrayBytes(f, 4, true)
+	if nil != err {
+		return nil, err
+	}
+	return &Float32SliceRW{Float32Slice{data: data, order: order}}, nil
+}
+
+// Set set the element at index i, it panics when i is out of range.
+func (s *Float32SliceRW) Set(i int, v float32) {
+	s.order.PutUint32(s.data[i*4:], math.Float32bits(v))
+}
+
+// Float64Slice is a read-only view of the mapping as an array of float64 in the byte order.
+// It's only valid until the File is closed or truncated.
+type Float64Slice struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// NewFloat64Slice view the mapping as an array of float64 in order,
+// the mapping length must be a multiple of 8 and aligned.
+func NewFloat64Slice(f *File, order binary.ByteOrder) (*Float64Slice, error) {
+	data, err := arrayBytes(f, 8, false)
+	if nil != err {
+		return nil, err
+	}
+	return &Float64Slice{data: data, order: order}, nil
+}
+
+// Len return the count of elements.
+func (s *Float64Slice) Len() int {
+	return len(s.data) / 8
+}
+
+// At return the element at index i, it panics when i is out of range.
+func (s *Float64Slice) At(i int) float64 {
+	u := s.order.Uint64(s.data[i*8:])
+	return math.Float64frombits(u)
+}
+
+// CopyTo copy the elements from index i to dst, return the count copied.
+func (s *Float64Slice) CopyTo(dst []float64, i int) int {
+	n := s.Len() - i
+	if n > len(dst) {
+		n = len(dst)
+	}
+	if n < 0 {
+		n = 0
+	}
+	for j := 0; j < n; j++ {
+		dst[j] = s.At(i + j)
+	}
+	return n
+}
+
+// Native return the mapping as []float64 without copy when the byte order is native, otherwise nil.
+// The returned slice has the same lifetime as the mapping, never retain it,
+// and never modify it when the view is read only.
+func (s *Float64Slice) Native() []float64 {
+	if s.order != nativeEndian {
+		return nil
+	}
+	var native []float64
+	castSlice(s.data, 8, unsafe.Pointer(&native))
+	return native
+}
+
+// Float64SliceRW is a read-write view of the mapping as an array of float64 in the byte order.
+type Float64SliceRW struct {
+	Float64Slice
+}
+
+// NewFloat64SliceRW view the writable mapping as an array of float64 in order,
+// the mapping length must be a multiple of 8 and aligned.
+func NewFloat64SliceRW(f *File, order binary.ByteOrder) (*Float64SliceRW, error) {
+	data, err := arrayBytes(f, 8, true)
+	if nil != err {
+		return nil, err
+	}
+	return &Float64SliceRW{Float64Slice{data: data, order: order}}, nil
+}
+
+// Set set the element at index i, it panics when i is out of range.
+func (s *Float64SliceRW) Set(i int, v float64) {
+	s.order.PutUint64(s.data[i*8:], math.Float64bits(v))
+}
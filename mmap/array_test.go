@@ -0,0 +1,104 @@
+package mmap
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInt64Slice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmap-array")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "timestamps")
+
+	data := make([]byte, 8*100)
+	for i := 0; i < 100; i++ {
+		binary.BigEndian.PutUint64(data[i*8:], uint64(i*1000-50))
+	}
+	if err = ioutil.WriteFile(filename, data, 0644); nil != err {
+		t.Fatal(err)
+	}
+
+	f, err := Open(filename)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s, err := NewInt64Slice(f, binary.BigEndian)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if s.Len() != 100 || s.At(0) != -50 || s.At(99) != 98950 {
+		t.Errorf("unexpected slice len %v, first %v, last %v", s.Len(), s.At(0), s.At(99))
+	}
+	dst := make([]int64, 10)
+	if n := s.CopyTo(dst, 95); n != 5 || dst[4] != 98950 {
+		t.Errorf("unexpected copied %v, %v", n, dst)
+	}
+	if n := s.CopyTo(dst, 200); n != 0 {
+		t.Errorf("unexpected copied %v", n)
+	}
+	if _, err = NewInt64SliceRW(f, binary.BigEndian); err != ErrReadOnly {
+		t.Errorf("expected read only error, but %v", err)
+	}
+	if nativeEndian == binary.LittleEndian && nil != s.Native() {
+		t.Error("expected nil native slice of big endian")
+	}
+
+	ioutil.WriteFile(filename, data[:799], 0644)
+	bad, err := Open(filename)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer bad.Close()
+	if _, err = NewInt64Slice(bad, binary.BigEndian); nil == err {
+		t.Error("expected size error")
+	}
+}
+
+func TestFloat64SliceRW(t *testing.T) {
+	f, err := Anonymous(8*64, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s, err := NewFloat64SliceRW(f, nativeEndian)
+	if nil != err {
+		t.Fatal(err)
+	}
+	for i := 0; i < s.Len(); i++ {
+		s.Set(i, float64(i)/2)
+	}
+	if s.At(3) != 1.5 || math.Float64frombits(nativeEndian.Uint64(f.Bytes()[24:])) != 1.5 {
+		t.Errorf("unexpected element %v", s.At(3))
+	}
+	native := s.Native()
+	if len(native) != 64 || native[63] != 31.5 {
+		t.Fatalf("unexpected native slice len %v", len(native))
+	}
+	native[0] = -1
+	if s.At(0) != -1 {
+		t.Errorf("expected write through native slice, but %v", s.At(0))
+	}
+
+	region, err := Anonymous(8*64, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer region.Close()
+	// simulate an unaligned mapping.
+	region.data = region.data[1:5]
+	if _, err = NewUint32Slice(region, binary.LittleEndian); nil == err {
+		t.Error("expected alignment error")
+	}
+	if u, err := NewUint16Slice(region, binary.LittleEndian); nil == err {
+		t.Errorf("expected alignment error, but %v elements", u.Len())
+	}
+}
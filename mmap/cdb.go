@@ -0,0 +1,282 @@
+package mmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// CDB is a read-only constant database of key/value pairs on a mapped file, in the format of
+// D. J. Bernstein's cdb, so the files are compatible with cdbmake and the other cdb tools.
+// Lookups return the bytes of mapping without copy, it's safe for concurrent use.
+//
+// The file layout, all numbers are uint32 little endian:
+//
+//	offset 0: 256 table pointers of (position, slots), 2048 bytes.
+//	offset 2048: records of (key length, value length, key, value).
+//	then: 256 hash tables, every slot is (hash, record position), position 0 is an empty slot.
+//
+// The hash is djb's h = ((h << 5) + h) ^ c from 5381, the low 8 bits choose the table,
+// and the rest choose the start slot of linear probing.
+type CDB struct {
+	file *File
+	data []byte
+	len  int
+}
+
+// CDBWriter build a cdb file, the pairs are written to a temporary file
+// which replace the target file at Close, so readers never see a partial file.
+// It's not safe for concurrent use.
+type CDBWriter struct {
+	filename string
+	file     *os.File
+	buf      *bufio.Writer
+	pos      int64
+	tables   [cdbTableCount][]cdbSlot
+	err      error
+}
+
+type cdbSlot struct {
+	hash uint32
+	pos  uint32
+}
+
+const (
+	cdbTableCount   = 256
+	cdbHeaderLen    = cdbTableCount * 8
+	cdbMaxFileSize  = 1<<32 - 1
+	cdbTempSuffix   = ".tmp"
+	cdbHashInitial  = 5381
+	cdbRecordHeader = 8
+)
+
+var ErrCDBTooLarge = errors.New("mmap: cdb file exceeds 4 GB")
+
+func cdbHash(key []byte) uint32 {
+	h := uint32(cdbHashInitial)
+	for _, c := range key {
+		h = ((h << 5) + h) ^ uint32(c)
+	}
+	return h
+}
+
+// CreateCDB create a writer of the cdb file.
+func CreateCDB(filename string) (*CDBWriter, error) {
+	file, err := os.OpenFile(filename+cdbTempSuffix, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if nil != err {
+		return nil, err
+	}
+	w := &CDBWriter{
+		filename: filename,
+		file:     file,
+		buf:      bufio.NewWriterSize(file, 64*1024),
+		pos:      cdbHeaderLen,
+	}
+	// reserve the header, it's written at Close.
+	if _, err = w.buf.Write(make([]byte, cdbHeaderLen)); nil != err {
+		w.Abort()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Put add a pair, the same key can be put many times, Get return the first one.
+func (w *CDBWriter) Put(key, value []byte) error {
+	if nil != w.err {
+		return w.err
+	}
+	size := int64(cdbRecordHeader + len(key) + len(value))
+	// leave space for the two slots of this pair.
+	if w.pos+size+int64(w.slots()+1)*16 > cdbMaxFileSize {
+		return ErrCDBTooLarge
+	}
+
+	var header [cdbRecordHeader]byte
+	binary.LittleEndian.PutUint32(header[:], uint32(len(key)))
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(value)))
+	for _, b := range [][]byte{header[:], key, value} {
+		if _, err := w.buf.Write(b); nil != err {
+			w.err = err
+			return err
+		}
+	}
+
+	h := cdbHash(key)
+	w.tables[h&0xff] = append(w.tables[h&0xff], cdbSlot{hash: h, pos: uint32(w.pos)})
+	w.pos += size
+	return nil
+}
+
+func (w *CDBWriter) slots() int {
+	n := 0
+	for _, table := range w.tables {
+		n += len(table)
+	}
+	return n
+}
+
+// Close write the hash tables, sync and rename the file to target.
+func (w *CDBWriter) Close() error {
+	if nil != w.err {
+		w.Abort()
+		return w.err
+	}
+
+	var header [cdbHeaderLen]byte
+	var entry [8]byte
+	for i, table := range w.tables {
+		n := len(table) * 2
+		binary.LittleEndian.PutUint32(header[i*8:], uint32(w.pos))
+		binary.LittleEndian.PutUint32(header[i*8+4:], uint32(n))
+
+		slots := make([]cdbSlot, n)
+		for _, slot := range table {
+			j := int(slot.hash>>8) % n
+			for slots[j].pos != 0 {
+				j = (j + 1) % n
+			}
+			slots[j] = slot
+		}
+		for _, slot := range slots {
+			binary.LittleEndian.PutUint32(entry[:], slot.hash)
+			binary.LittleEndian.PutUint32(entry[4:], slot.pos)
+			if _, err := w.buf.Write(entry[:]); nil != err {
+				w.Abort()
+				return err
+			}
+		}
+		w.pos += int64(n) * 8
+	}
+
+	err := w.buf.Flush()
+	if nil == err {
+		_, err = w.file.WriteAt(header[:], 0)
+	}
+	if nil == err {
+		err = w.file.Sync()
+	}
+	if nil != err {
+		w.Abort()
+		return err
+	}
+	if err = w.file.Close(); nil != err {
+		os.Remove(w.filename + cdbTempSuffix)
+		return err
+	}
+	w.err = os.ErrClosed
+	return os.Rename(w.filename+cdbTempSuffix, w.filename)
+}
+
+// Abort discard the written pairs, the target file is untouched.
+func (w *CDBWriter) Abort() error {
+	w.err = os.ErrClosed
+	w.file.Close()
+	return os.Remove(w.filename + cdbTempSuffix)
+}
+
+// OpenCDB map the cdb file and validate it's tables.
+func OpenCDB(filename string) (*CDB, error) {
+	file, err := Open(filename)
+	if nil != err {
+		return nil, err
+	}
+	c := &CDB{file: file, data: file.Bytes()}
+	if err = c.validate(); nil != err {
+		file.Close()
+		return nil, fmt.Errorf("mmap: invalid cdb file %v: %v", filename, err)
+	}
+	return c, nil
+}
+
+func (c *CDB) validate() error {
+	if len(c.data) < cdbHeaderLen {
+		return io.ErrUnexpectedEOF
+	}
+	for i := 0; i < cdbTableCount; i++ {
+		pos, n := c.uint32Pair(i * 8)
+		if pos < cdbHeaderLen || int64(pos)+int64(n)*8 > int64(len(c.data)) {
+			return fmt.Errorf("table %v out of range", i)
+		}
+		c.len += int(n) / 2
+	}
+	return nil
+}
+
+func (c *CDB) uint32Pair(off int) (uint32, uint32) {
+	return binary.LittleEndian.Uint32(c.data[off:]), binary.LittleEndian.Uint32(c.data[off+4:])
+}
+
+// record return the key and value of record at pos, ok is false when it's out of range.
+func (c *CDB) record(pos uint32) (key, value []byte, ok bool) {
+	off := int64(pos)
+	if off+cdbRecordHeader > int64(len(c.data)) {
+		return nil, nil, false
+	}
+	klen, vlen := c.uint32Pair(int(off))
+	off += cdbRecordHeader
+	if off+int64(klen)+int64(vlen) > int64(len(c.data)) {
+		return nil, nil, false
+	}
+	key = c.data[off : off+int64(klen)]
+	value = c.data[off+int64(klen) : off+int64(klen)+int64(vlen)]
+	return key, value, true
+}
+
+// Get return the value of the first pair of key.
+// The value is the bytes of mapping, it's only valid until Close, never retain or modify it.
+func (c *CDB) Get(key []byte) ([]byte, bool) {
+	h := cdbHash(key)
+	tpos, n := c.uint32Pair(int(h&0xff) * 8)
+	if n == 0 {
+		return nil, false
+	}
+	j := (h >> 8) % n
+	for i := uint32(0); i < n; i++ {
+		hash, pos := c.uint32Pair(int(tpos + j*8))
+		if pos == 0 {
+			return nil, false
+		}
+		if hash == h {
+			k, v, ok := c.record(pos)
+			if ok && bytes.Equal(k, key) {
+				return v, true
+			}
+		}
+		if j++; j == n {
+			j = 0
+		}
+	}
+	return nil, false
+}
+
+// Len return the count of pairs.
+func (c *CDB) Len() int {
+	return c.len
+}
+
+// ForEach call fn with every pair in the order of Put, stop when fn return false.
+// The key and value are the bytes of mapping, they are only valid until Close.
+func (c *CDB) ForEach(fn func(key, value []byte) bool) error {
+	// the records end at the first hash table.
+	end, _ := c.uint32Pair(0)
+	for pos := uint32(cdbHeaderLen); pos < end; {
+		key, value, ok := c.record(pos)
+		if !ok {
+			return fmt.Errorf("mmap: cdb record at %v out of range", pos)
+		}
+		if !fn(key, value) {
+			return nil
+		}
+		pos += cdbRecordHeader + uint32(len(key)) + uint32(len(value))
+	}
+	return nil
+}
+
+// Close unmap the file, call it after all lookups are done.
+func (c *CDB) Close() error {
+	return c.file.Close()
+}
@@ -0,0 +1,120 @@
+package mmap
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestCDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmap-cdb")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.cdb")
+
+	w, err := CreateCDB(filename)
+	if nil != err {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if err = w.Put([]byte(fmt.Sprintf("key-%v", i)), []byte(fmt.Sprintf("value-%v", i))); nil != err {
+			t.Fatal(err)
+		}
+	}
+	w.Put([]byte("key-1"), []byte("duplicate"))
+	w.Put([]byte(""), []byte("empty key"))
+	if _, err = os.Stat(filename); !os.IsNotExist(err) {
+		t.Error("expected file not exists before close")
+	}
+	if err = w.Close(); nil != err {
+		t.Fatal(err)
+	}
+
+	c, err := OpenCDB(filename)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.Len() != 1002 {
+		t.Errorf("unexpected len %v", c.Len())
+	}
+	if value, ok := c.Get([]byte("key-1")); !ok || string(value) != "value-1" {
+		t.Errorf("unexpected value %q of key-1", value)
+	}
+	if value, ok := c.Get([]byte("")); !ok || string(value) != "empty key" {
+		t.Errorf("unexpected value %q of empty key", value)
+	}
+	if _, ok := c.Get([]byte("missing")); ok {
+		t.Error("expected missing key not found")
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				value, ok := c.Get([]byte(fmt.Sprintf("key-%v", i)))
+				if !ok || (i != 1 && string(value) != fmt.Sprintf("value-%v", i)) {
+					t.Errorf("unexpected value %q of key-%v", value, i)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	count := 0
+	err = c.ForEach(func(key, value []byte) bool {
+		if count < 1000 && string(key) != fmt.Sprintf("key-%v", count) {
+			t.Errorf("unexpected key %q at %v", key, count)
+		}
+		count++
+		return true
+	})
+	if nil != err || count != 1002 {
+		t.Errorf("unexpected count %v, err: %v", count, err)
+	}
+	count = 0
+	c.ForEach(func(key, value []byte) bool {
+		count++
+		return count < 10
+	})
+	if count != 10 {
+		t.Errorf("expected stop at 10, but %v", count)
+	}
+}
+
+func TestCDBWriter_Abort(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmap-cdb")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.cdb")
+
+	w, err := CreateCDB(filename)
+	if nil != err {
+		t.Fatal(err)
+	}
+	w.Put([]byte("key"), []byte("value"))
+	if err = w.Abort(); nil != err {
+		t.Error(err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected no file left, but %v", len(files))
+	}
+	if err = w.Put([]byte("key"), []byte("value")); err != os.ErrClosed {
+		t.Errorf("expected closed error, but %v", err)
+	}
+
+	ioutil.WriteFile(filename, []byte("not a cdb file"), 0644)
+	if _, err = OpenCDB(filename); nil == err {
+		t.Error("expected invalid file error")
+	}
+}
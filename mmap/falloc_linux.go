@@ -0,0 +1,16 @@
+package mmap
+
+import (
+	"os"
+	"syscall"
+)
+
+// preallocate allocate the disk blocks of file up to size, so write the mapping never fault when disk is full.
+// It fallback to truncate when the file system not support fallocate.
+func preallocate(file *os.File, size int64) error {
+	err := syscall.Fallocate(int(file.Fd()), 0, 0, size)
+	if err == syscall.EOPNOTSUPP || err == syscall.ENOSYS {
+		return file.Truncate(size)
+	}
+	return err
+}
@@ -0,0 +1,32 @@
+package mmap
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestPreallocate(t *testing.T) {
+	file, err := ioutil.TempFile("", "mmap-falloc")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err = preallocate(file, 1<<20); nil != err {
+		t.Fatal(err)
+	}
+	fstat, err := file.Stat()
+	if nil != err || fstat.Size() != 1<<20 {
+		t.Fatalf("unexpected size %v, err: %v", fstat.Size(), err)
+	}
+	// the blocks are allocated, not a sparse file, unless fallback to truncate.
+	if blocks := fstat.Sys().(*syscall.Stat_t).Blocks; blocks*512 < 1<<20 {
+		if err = syscall.Fallocate(int(file.Fd()), 0, 0, 1<<20); err == syscall.EOPNOTSUPP {
+			t.Skip("the file system not support fallocate")
+		}
+		t.Errorf("expected blocks allocated, but %v blocks", blocks)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package mmap
+
+import (
+	"os"
+)
+
+// preallocate extend file to size, the file is sparse,
+// write the mapping may fault when disk is full.
+func preallocate(file *os.File, size int64) error {
+	return file.Truncate(size)
+}
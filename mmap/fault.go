@@ -0,0 +1,92 @@
+package mmap
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ErrFault is returned in safe access mode, when access the mapping pages faulted,
+// usually the file is truncated by other process.
+var ErrFault = errors.New("mmap: fault when access the mapping, the file may be truncated")
+
+// SetSafeAccess enable or disable safe access mode.
+// Access the mapping pages beyond the end of file raise SIGBUS and crash the process,
+// in safe access mode, ReadAt, At, WriteAt, Reader and Prefault return ErrFault instead of crash.
+// Bytes is never guarded, use Stale to check the file size.
+// It's use debug.SetPanicOnFault, every access cost a little more.
+// Before go 1.17 the fault address is unknown, any memory error in the guarded access is ErrFault.
+func (f *File) SetSafeAccess(enabled bool) {
+	var safe int32
+	if enabled {
+		safe = 1
+	}
+	atomic.StoreInt32(&f.safe, safe)
+}
+
+// Stale report whether the file size is changed since mapped,
+// when the file is shrunk, access the truncated pages will fault.
+func (f *File) Stale() (bool, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	if nil == f.data {
+		return false, os.ErrClosed
+	}
+	if nil == f.file {
+		return false, nil
+	}
+	fstat, err := f.file.Stat()
+	if nil != err {
+		return false, err
+	}
+	return fstat.Size() != f.FileInfo.Size(), nil
+}
+
+// guard call fn, convert the fault of the mapping into ErrFault in safe access mode.
+// The caller must hold the mutex.
+func (f *File) guard(fn func()) (err error) {
+	if atomic.LoadInt32(&f.safe) == 0 {
+		fn()
+		return nil
+	}
+
+	defer func() {
+		r := recover()
+		if nil == r {
+			return
+		}
+		switch fault := r.(type) {
+		case interface{ Addr() uintptr }:
+			// Since go 1.17, the fault error of runtime has Addr method.
+			if f.contains(fault.Addr()) {
+				err = ErrFault
+				return
+			}
+		case runtime.Error:
+			// Before go 1.17, the fault address is unknown, fn only access the mapping,
+			// so the memory error is the fault of the mapping.
+			if strings.Contains(fault.Error(), "invalid memory address") {
+				err = ErrFault
+				return
+			}
+		}
+		panic(r)
+	}()
+
+	old := debug.SetPanicOnFault(true)
+	defer debug.SetPanicOnFault(old)
+	fn()
+	return nil
+}
+
+func (f *File) contains(addr uintptr) bool {
+	if len(f.mapped) == 0 {
+		return false
+	}
+	start := uintptr(unsafe.Pointer(&f.mapped[0]))
+	return start <= addr && addr < start+uintptr(len(f.mapped))
+}
@@ -0,0 +1,95 @@
+package mmap
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestFile_SetSafeAccess(t *testing.T) {
+	pageSize := os.Getpagesize()
+	filename, expected, cleanup := createTestFile(t, 3*pageSize)
+	defer cleanup()
+
+	file, err := OpenFile(filename, os.O_RDWR, 0)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.SetSafeAccess(true)
+
+	buf := make([]byte, 16)
+	if _, err = file.ReadAt(buf, int64(2*pageSize)); nil != err || !bytes.Equal(buf, expected[2*pageSize:2*pageSize+16]) {
+		t.Errorf("unexpected ReadAt %v, err: %v", buf, err)
+	}
+	if stale, err := file.Stale(); nil != err || stale {
+		t.Errorf("expected not stale, but %v, err: %v", stale, err)
+	}
+
+	// Truncated by other process, like log rotation.
+	if err = os.Truncate(filename, int64(pageSize)); nil != err {
+		t.Fatal(err)
+	}
+	if stale, err := file.Stale(); nil != err || !stale {
+		t.Errorf("expected stale, but %v, err: %v", stale, err)
+	}
+
+	if _, err = file.ReadAt(buf, int64(2*pageSize)); err != ErrFault {
+		t.Errorf("expected fault error, but %v", err)
+	}
+	if _, err = file.At(2 * pageSize); err != ErrFault {
+		t.Errorf("expected fault error, but %v", err)
+	}
+	if _, err = file.WriteAt(buf, int64(2*pageSize)); err != ErrFault {
+		t.Errorf("expected fault error, but %v", err)
+	}
+	var out bytes.Buffer
+	if _, err = file.Section(0, int64(file.Len())).WriteTo(&out); err != ErrFault {
+		t.Errorf("expected fault error, but %v", err)
+	}
+	if err = file.Prefault(); err != ErrFault {
+		t.Errorf("expected fault error, but %v", err)
+	}
+
+	// The pages in the file are still readable.
+	if _, err = file.ReadAt(buf, 0); nil != err || !bytes.Equal(buf, expected[:16]) {
+		t.Errorf("unexpected ReadAt %v, err: %v", buf, err)
+	}
+
+	// Remap to the current size.
+	if err = file.Truncate(int64(pageSize)); nil != err {
+		t.Fatal(err)
+	}
+	if stale, err := file.Stale(); nil != err || stale {
+		t.Errorf("expected not stale after remap, but %v, err: %v", stale, err)
+	}
+}
+
+// memoryError is the fault error of runtime before go 1.17, it has no Addr method.
+type memoryError struct{}
+
+func (memoryError) RuntimeError() {}
+
+func (memoryError) Error() string {
+	return "runtime error: invalid memory address or nil pointer dereference"
+}
+
+func TestFile_GuardWithoutAddr(t *testing.T) {
+	file, err := Anonymous(os.Getpagesize(), false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.SetSafeAccess(true)
+
+	if err = file.guard(func() { panic(memoryError{}) }); err != ErrFault {
+		t.Errorf("expected fault error, but %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r != "other" {
+			t.Errorf("expected other panic re-panicked, but %v", r)
+		}
+	}()
+	file.guard(func() { panic("other") })
+}
@@ -0,0 +1,136 @@
+//go:build ignore
+// +build ignore
+
+// gen_array.go generate array_gen.go, the typed arrays of fixed-size numbers over mapping.
+// Run it by go generate in the mmap directory.
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"text/template"
+)
+
+type element struct {
+	Name   string // exported name prefix, e.g. Int64.
+	Type   string // go type, e.g. int64.
+	Size   int    // bytes of element.
+	Bits   int    // bits of the unsigned integer in encoding/binary.
+	Decode string // expression converts uint named u to Type.
+	Encode string // expression converts Type named v to uint.
+}
+
+var elements = []element{
+	{"Int16", "int16", 2, 16, "int16(u)", "uint16(v)"},
+	{"Uint16", "uint16", 2, 16, "u", "v"},
+	{"Int32", "int32", 4, 32, "int32(u)", "uint32(v)"},
+	{"Uint32", "uint32", 4, 32, "u", "v"},
+	{"Int64", "int64", 8, 64, "int64(u)", "uint64(v)"},
+	{"Uint64", "uint64", 8, 64, "u", "v"},
+	{"Float32", "float32", 4, 32, "math.Float32frombits(u)", "math.Float32bits(v)"},
+	{"Float64", "float64", 8, 64, "math.Float64frombits(u)", "math.Float64bits(v)"},
+}
+
+var tmpl = template.Must(template.New("array").Parse(`// Code generated by gen_array.go; DO NOT EDIT.
+
+package mmap
+
+import (
+	"encoding/binary"
+	"math"
+	"unsafe"
+)
+
+var _ = math.Float64bits
+{{range .}}
+// {{.Name}}Slice is a read-only view of the mapping as an array of {{.Type}} in the byte order.
+// It's only valid until the File is closed or truncated.
+type {{.Name}}Slice struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// New{{.Name}}Slice view the mapping as an array of {{.Type}} in order,
+// the mapping length must be a multiple of {{.Size}} and aligned.
+func New{{.Name}}Slice(f *File, order binary.ByteOrder) (*{{.Name}}Slice, error) {
+	data, err := arrayBytes(f, {{.Size}}, false)
+	if nil != err {
+		return nil, err
+	}
+	return &{{.Name}}Slice{data: data, order: order}, nil
+}
+
+// Len return the count of elements.
+func (s *{{.Name}}Slice) Len() int {
+	return len(s.data) / {{.Size}}
+}
+
+// At return the element at index i, it panics when i is out of range.
+func (s *{{.Name}}Slice) At(i int) {{.Type}} {
+	u := s.order.Uint{{.Bits}}(s.data[i*{{.Size}}:])
+	return {{.Decode}}
+}
+
+// CopyTo copy the elements from index i to dst, return the count copied.
+func (s *{{.Name}}Slice) CopyTo(dst []{{.Type}}, i int) int {
+	n := s.Len() - i
+	if n > len(dst) {
+		n = len(dst)
+	}
+	if n < 0 {
+		n = 0
+	}
+	for j := 0; j < n; j++ {
+		dst[j] = s.At(i + j)
+	}
+	return n
+}
+
+// Native return the mapping as []{{.Type}} without copy when the byte order is native, otherwise nil.
+// The returned slice has the same lifetime as the mapping, never retain it,
+// and never modify it when the view is read only.
+func (s *{{.Name}}Slice) Native() []{{.Type}} {
+	if s.order != nativeEndian {
+		return nil
+	}
+	var native []{{.Type}}
+	castSlice(s.data, {{.Size}}, unsafe.Pointer(&native))
+	return native
+}
+
+// {{.Name}}SliceRW is a read-write view of the mapping as an array of {{.Type}} in the byte order.
+type {{.Name}}SliceRW struct {
+	{{.Name}}Slice
+}
+
+// New{{.Name}}SliceRW view the writable mapping as an array of {{.Type}} in order,
+// the mapping length must be a multiple of {{.Size}} and aligned.
+func New{{.Name}}SliceRW(f *File, order binary.ByteOrder) (*{{.Name}}SliceRW, error) {
+	data, err := arrayBytes(f, {{.Size}}, true)
+	if nil != err {
+		return nil, err
+	}
+	return &{{.Name}}SliceRW{ {{.Name}}Slice{data: data, order: order} }, nil
+}
+
+// Set set the element at index i, it panics when i is out of range.
+func (s *{{.Name}}SliceRW) Set(i int, v {{.Type}}) {
+	s.order.PutUint{{.Bits}}(s.data[i*{{.Size}}:], {{.Encode}})
+}
+{{end}}`))
+
+func main() {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, elements); nil != err {
+		log.Fatal(err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if nil != err {
+		log.Fatal(err)
+	}
+	if err = ioutil.WriteFile("array_gen.go", src, 0644); nil != err {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package mmap
+
+import (
+	"errors"
+)
+
+func mlock(b []byte) error {
+	return errors.New("mmap: mlock is not supported on this platform")
+}
+
+func munlock(b []byte) error {
+	return errors.New("mmap: munlock is not supported on this platform")
+}
+
+func mincore(b []byte, vec []byte) error {
+	return errors.New("mmap: mincore is not supported on this platform")
+}
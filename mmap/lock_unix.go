@@ -0,0 +1,27 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package mmap
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func mlock(b []byte) error {
+	return syscall.Mlock(b)
+}
+
+func munlock(b []byte) error {
+	return syscall.Munlock(b)
+}
+
+// mincore set the least significant bit of vec[i] when the i-th page of b is resident.
+func mincore(b []byte, vec []byte) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_MINCORE,
+		uintptr(unsafe.Pointer(&b[0])), uintptr(len(b)), uintptr(unsafe.Pointer(&vec[0])))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,411 @@
+package mmap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Log is a segment based append-only log on preallocated mapped files, it's safe for concurrent use.
+//
+// Every record is 4 bytes little endian payload length, 4 bytes little endian CRC32-C
+// of the length bytes and payload, then the payload. The rest of a segment is zero.
+// A record is addressed by it's logical offset, the segment file name is the logical offset
+// of it's first record, e.g. "00000000000000001024.log".
+// When open, the torn tail of the last segment after a crash is truncated.
+//
+// The segment files are preallocated by fallocate on linux, so Append never fault when disk is full.
+// On other platforms the segment files are sparse, the segments are mapped in safe access mode,
+// Append return ErrFault instead of crash when the disk is full.
+type Log struct {
+	dir         string
+	segmentSize int64
+
+	mutex    sync.RWMutex
+	segments []*logSegment // sorted by base, the last one is active.
+	closed   bool
+}
+
+// LogOptions is the options of OpenLog.
+type LogOptions struct {
+	SegmentSize int64 // preallocated segment file size, default 64 MB.
+}
+
+type logSegment struct {
+	base int64 // logical offset of the first byte.
+	size int64 // valid bytes.
+	file *File
+}
+
+const (
+	logRecordHeaderLen     = 8
+	logSegmentSuffix       = ".log"
+	defaultLogSegmentSize  = 64 << 20
+	logRecoverZeroPageSize = 4096
+)
+
+var (
+	ErrCorrupt        = errors.New("mmap: log record is corrupt")
+	ErrRecordTooLarge = errors.New("mmap: log record is larger than segment")
+
+	crc32c = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// OpenLog open or create the log in dir, and recover the torn tail.
+func OpenLog(dir string, options *LogOptions) (*Log, error) {
+	l := &Log{dir: dir, segmentSize: defaultLogSegmentSize}
+	if nil != options && options.SegmentSize > 0 {
+		l.segmentSize = options.SegmentSize
+	}
+	if err := os.MkdirAll(dir, 0755); nil != err {
+		return nil, err
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if nil != err {
+		return nil, err
+	}
+	var bases []int64
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasSuffix(name, logSegmentSuffix) {
+			continue
+		}
+		base, err := strconv.ParseInt(strings.TrimSuffix(name, logSegmentSuffix), 10, 64)
+		if nil != err {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+
+	for i, base := range bases {
+		if i == len(bases)-1 {
+			// crashed when preallocate the active segment, it's empty or shorter than segment size.
+			if err = extendSegment(l.segmentName(base), l.segmentSize); nil != err {
+				l.Close()
+				return nil, err
+			}
+		}
+		file, err := OpenFile(l.segmentName(base), os.O_RDWR, 0)
+		if nil != err {
+			l.Close()
+			return nil, err
+		}
+		file.SetSafeAccess(true)
+		segment := &logSegment{base: base, file: file}
+		segment.size = segment.recover(i == len(bases)-1)
+		l.segments = append(l.segments, segment)
+	}
+
+	if len(l.segments) == 0 {
+		if err = l.roll(0); nil != err {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (l *Log) segmentName(base int64) string {
+	return filepath.Join(l.dir, fmt.Sprintf("%020d%s", base, logSegmentSuffix))
+}
+
+// roll create a new active segment from base.
+func (l *Log) roll(base int64) error {
+	filename := l.segmentName(base)
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if nil != err {
+		return err
+	}
+	err = preallocate(file, l.segmentSize)
+	file.Close()
+	if nil != err {
+		os.Remove(filename)
+		return err
+	}
+
+	mapped, err := OpenFile(filename, os.O_RDWR, 0)
+	if nil != err {
+		os.Remove(filename)
+		return err
+	}
+	mapped.SetSafeAccess(true)
+	l.segments = append(l.segments, &logSegment{base: base, file: mapped})
+	return nil
+}
+
+// extendSegment preallocate the segment file to size when it's smaller, the content is kept.
+func extendSegment(filename string, size int64) error {
+	file, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if nil != err {
+		return err
+	}
+	defer file.Close()
+	fstat, err := file.Stat()
+	if nil != err {
+		return err
+	}
+	if fstat.Size() >= size {
+		return nil
+	}
+	return preallocate(file, size)
+}
+
+// recover scan the valid records, return the valid size,
+// zero the torn tail when truncate is true.
+func (s *logSegment) recover(truncate bool) int64 {
+	data := s.file.Bytes()
+	var off int64
+	for {
+		n, err := readLogRecord(data, off)
+		if nil != err {
+			break
+		}
+		off += logRecordHeaderLen + int64(n)
+	}
+
+	if truncate {
+		// only write the dirty pages, keep the preallocated pages clean.
+		for i := off; i < int64(len(data)); {
+			end := (i/logRecoverZeroPageSize + 1) * logRecoverZeroPageSize
+			if end > int64(len(data)) {
+				end = int64(len(data))
+			}
+			page := data[i:end]
+			for j := range page {
+				if page[j] != 0 {
+					for k := range page {
+						page[k] = 0
+					}
+					break
+				}
+			}
+			i = end
+		}
+	}
+	return off
+}
+
+// readLogRecord return the payload length of the record at off, or error when it's invalid.
+func readLogRecord(data []byte, off int64) (int, error) {
+	if int64(len(data))-off < logRecordHeaderLen {
+		return 0, io.EOF
+	}
+	header := data[off : off+logRecordHeaderLen]
+	n := binary.LittleEndian.Uint32(header)
+	sum := binary.LittleEndian.Uint32(header[4:])
+	if n == 0 && sum == 0 {
+		return 0, io.EOF
+	}
+	if int64(n) > int64(len(data))-off-logRecordHeaderLen {
+		return 0, ErrCorrupt
+	}
+	payload := data[off+logRecordHeaderLen : off+logRecordHeaderLen+int64(n)]
+	if logChecksum(header[:4], payload) != sum {
+		return 0, ErrCorrupt
+	}
+	return int(n), nil
+}
+
+func logChecksum(length, payload []byte) uint32 {
+	return crc32.Update(crc32.Checksum(length, crc32c), crc32c, payload)
+}
+
+// Append append a record, return it's logical offset.
+// The record is visible to readers at once, but not durable until Sync.
+func (l *Log) Append(record []byte) (int64, error) {
+	size := logRecordHeaderLen + int64(len(record))
+	if size > l.segmentSize {
+		return 0, ErrRecordTooLarge
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.closed {
+		return 0, os.ErrClosed
+	}
+
+	active := l.segments[len(l.segments)-1]
+	if active.size+size > int64(active.file.Len()) {
+		// the previous segment is never written again, make it durable.
+		if err := active.file.FlushRange(0, int(active.size), Sync); nil != err {
+			return 0, err
+		}
+		if err := l.roll(active.base + active.size); nil != err {
+			return 0, err
+		}
+		active = l.segments[len(l.segments)-1]
+	}
+
+	data := active.file.Bytes()[active.size:]
+	err := active.file.guard(func() {
+		copy(data[logRecordHeaderLen:], record)
+		binary.LittleEndian.PutUint32(data, uint32(len(record)))
+		binary.LittleEndian.PutUint32(data[4:], logChecksum(data[:4], record))
+	})
+	if nil != err {
+		return 0, err
+	}
+
+	offset := active.base + active.size
+	active.size += size
+	return offset, nil
+}
+
+// ReadAt return a copy of the record at logical offset, and the offset of next record.
+// It's return io.EOF when offset is the end of log.
+func (l *Log) ReadAt(offset int64) (record []byte, next int64, err error) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if l.closed {
+		return nil, 0, os.ErrClosed
+	}
+
+	i := sort.Search(len(l.segments), func(i int) bool {
+		return l.segments[i].base+l.segments[i].size > offset
+	})
+	if i == len(l.segments) {
+		if offset == l.end() {
+			return nil, 0, io.EOF
+		}
+		return nil, 0, fmt.Errorf("mmap: log offset %v out of range", offset)
+	}
+	segment := l.segments[i]
+	if offset < segment.base {
+		return nil, 0, fmt.Errorf("mmap: log offset %v out of range", offset)
+	}
+
+	off := offset - segment.base
+	data := segment.file.Bytes()[:segment.size]
+	n, err := readLogRecord(data, off)
+	if nil != err {
+		if err == io.EOF {
+			// not the record boundary.
+			err = ErrCorrupt
+		}
+		return nil, 0, err
+	}
+	record = make([]byte, n)
+	copy(record, data[off+logRecordHeaderLen:])
+	return record, offset + logRecordHeaderLen + int64(n), nil
+}
+
+// Begin return the logical offset of the first record.
+func (l *Log) Begin() int64 {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if len(l.segments) == 0 {
+		return 0
+	}
+	return l.segments[0].base
+}
+
+// End return the logical offset of the next appended record.
+func (l *Log) End() int64 {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.end()
+}
+
+func (l *Log) end() int64 {
+	if len(l.segments) == 0 {
+		return 0
+	}
+	active := l.segments[len(l.segments)-1]
+	return active.base + active.size
+}
+
+// Sync write the appended records of active segment to disk.
+func (l *Log) Sync() error {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if l.closed {
+		return os.ErrClosed
+	}
+	active := l.segments[len(l.segments)-1]
+	return active.file.FlushRange(0, int(active.size), Sync)
+}
+
+// Close sync and unmap all segments.
+func (l *Log) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+
+	var err error
+	for i, segment := range l.segments {
+		if i == len(l.segments)-1 {
+			if syncErr := segment.file.FlushRange(0, int(segment.size), Sync); nil == err {
+				err = syncErr
+			}
+		}
+		if closeErr := segment.file.Close(); nil == err {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Iterator return an iterator of records from logical offset.
+func (l *Log) Iterator(offset int64) *LogIterator {
+	return &LogIterator{log: l, next: offset}
+}
+
+// LogIterator iterate the records, it's not safe for concurrent use.
+//
+//	it := log.Iterator(log.Begin())
+//	for it.Next() {
+//		process(it.Offset(), it.Record())
+//	}
+//	err := it.Err()
+type LogIterator struct {
+	log    *Log
+	offset int64
+	next   int64
+	record []byte
+	err    error
+}
+
+// Next move to the next record, return false at the end of log or error.
+// The records appended later can be iterated by calling Next again.
+func (it *LogIterator) Next() bool {
+	if nil != it.err {
+		return false
+	}
+	record, next, err := it.log.ReadAt(it.next)
+	if nil != err {
+		if err != io.EOF {
+			it.err = err
+		}
+		return false
+	}
+	it.offset, it.next, it.record = it.next, next, record
+	return true
+}
+
+// Record return the current record.
+func (it *LogIterator) Record() []byte {
+	return it.record
+}
+
+// Offset return the logical offset of current record.
+func (it *LogIterator) Offset() int64 {
+	return it.offset
+}
+
+// Err return the error stopped the iteration, nil at the end of log.
+func (it *LogIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,187 @@
+package mmap
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLog_Append(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmap-log")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	log, err := OpenLog(dir, &LogOptions{SegmentSize: 256})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, err = log.Append(make([]byte, 256)); err != ErrRecordTooLarge {
+		t.Errorf("expected too large error, but %v", err)
+	}
+
+	var offsets []int64
+	for i := 0; i < 50; i++ {
+		offset, err := log.Append([]byte(fmt.Sprintf("record-%v", i)))
+		if nil != err {
+			t.Fatal(err)
+		}
+		offsets = append(offsets, offset)
+	}
+	if _, err = log.Append(nil); nil != err {
+		t.Error(err)
+	}
+	if err = log.Sync(); nil != err {
+		t.Error(err)
+	}
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(segments) < 3 {
+		t.Errorf("expected segments rollover, but %v segments", len(segments))
+	}
+
+	record, next, err := log.ReadAt(offsets[10])
+	if nil != err || string(record) != "record-10" || next != offsets[11] {
+		t.Errorf("unexpected record %q, next %v, err: %v", record, next, err)
+	}
+	if _, _, err = log.ReadAt(offsets[10] + 1); nil == err {
+		t.Error("expected error of not record boundary")
+	}
+	if _, _, err = log.ReadAt(log.End()); err != io.EOF {
+		t.Errorf("expected EOF, but %v", err)
+	}
+
+	it := log.Iterator(log.Begin())
+	count := 0
+	for it.Next() {
+		if count < 50 && (string(it.Record()) != fmt.Sprintf("record-%v", count) || it.Offset() != offsets[count]) {
+			t.Fatalf("unexpected record %q at %v", it.Record(), it.Offset())
+		}
+		count++
+	}
+	if nil != it.Err() || count != 51 || len(it.Record()) != 0 {
+		t.Errorf("unexpected iterate count %v, err: %v", count, it.Err())
+	}
+
+	// the iterator continue after append.
+	log.Append([]byte("later"))
+	if !it.Next() || string(it.Record()) != "later" {
+		t.Errorf("expected later record, but %q", it.Record())
+	}
+
+	if err = log.Close(); nil != err {
+		t.Error(err)
+	}
+	if _, err = log.Append([]byte("closed")); err != os.ErrClosed {
+		t.Errorf("expected closed error, but %v", err)
+	}
+}
+
+func TestOpenLog_Recover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmap-log")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	log, err := OpenLog(dir, &LogOptions{SegmentSize: 1024})
+	if nil != err {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		log.Append([]byte(fmt.Sprintf("record-%v", i)))
+	}
+	end := log.End()
+	last, _ := log.Append([]byte("torn record"))
+	log.Close()
+
+	// simulate crash when write the last record, the payload is half written.
+	filename := filepath.Join(dir, "00000000000000000000.log")
+	file, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if nil != err {
+		t.Fatal(err)
+	}
+	file.WriteAt([]byte("XXXX"), last+logRecordHeaderLen+5)
+	file.Close()
+
+	log, err = OpenLog(dir, &LogOptions{SegmentSize: 1024})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if log.End() != end {
+		t.Errorf("expected end %v after recover, but %v", end, log.End())
+	}
+	offset, err := log.Append([]byte("after recover"))
+	if nil != err || offset != end {
+		t.Errorf("unexpected offset %v, err: %v", offset, err)
+	}
+	log.Close()
+
+	log, err = OpenLog(dir, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	count := 0
+	for it := log.Iterator(0); it.Next(); count++ {
+		if count == 10 && string(it.Record()) != "after recover" {
+			t.Errorf("unexpected record %q", it.Record())
+		}
+	}
+	if count != 11 {
+		t.Errorf("expected 11 records, but %v", count)
+	}
+}
+
+func TestOpenLog_ShortSegment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmap-log")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	log, err := OpenLog(dir, &LogOptions{SegmentSize: 256})
+	if nil != err {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		if _, err = log.Append([]byte(fmt.Sprintf("record-%v", i))); nil != err {
+			t.Fatal(err)
+		}
+	}
+	end := log.End()
+	log.Close()
+
+	// simulate crash when preallocate the active segment, empty and half preallocated.
+	filename := filepath.Join(dir, fmt.Sprintf("%020d.log", end))
+	for _, size := range []int64{0, 100} {
+		if err = ioutil.WriteFile(filename, make([]byte, size), 0644); nil != err {
+			t.Fatal(err)
+		}
+		log, err = OpenLog(dir, &LogOptions{SegmentSize: 256})
+		if nil != err {
+			t.Fatal(err)
+		}
+		if log.End() != end {
+			t.Errorf("expected end %v, but %v", end, log.End())
+		}
+		for i := 0; i < 20; i++ {
+			if _, err = log.Append([]byte(fmt.Sprintf("record-%v", i))); nil != err {
+				t.Fatalf("append after reopen a segment of %v bytes: %v", size, err)
+			}
+		}
+		log.Close()
+		os.Remove(filename)
+		// remove the segments rolled after.
+		infos, _ := ioutil.ReadDir(dir)
+		for _, info := range infos {
+			if info.Name() > filepath.Base(filename) {
+				os.Remove(filepath.Join(dir, info.Name()))
+			}
+		}
+	}
+}
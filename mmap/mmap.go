@@ -1,65 +1,228 @@
 package mmap
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
+	"sync"
 	"syscall"
 )
 
+// O_PRIVATE is the OpenFile flag to create a copy-on-write MAP_PRIVATE mapping,
+// it's writable, but the modifications are not written back to the file.
+const O_PRIVATE = 0x40000000
+
+// SyncMode is the FlushRange mode.
+type SyncMode int
+
+const (
+	Sync  SyncMode = syscall.MS_SYNC  // wait the flush complete.
+	Async SyncMode = syscall.MS_ASYNC // schedule the flush and return at once.
+)
+
+var ErrReadOnly = errors.New("mmap: mapping is read only")
+
+// File is a memory mapped file, it's safe for concurrent use.
+// The accesses after Close return os.ErrClosed.
 type File struct {
 	FileInfo os.FileInfo
 	data     []byte
+
+	// guard data, Close and Truncate remap it.
+	mutex sync.RWMutex
+
+	// data is a view of mapped, they are different when the region offset is not page aligned.
+	mapped []byte
+
+	file   *os.File // nil for anonymous mapping.
+	prot   int
+	flags  int
+	region bool // MapRegion mapping, the file is not owned.
+	safe   int32
 }
 
 func Open(filename string) (*File, error) {
-	file, err := os.Open(filename)
+	return OpenFile(filename, os.O_RDONLY, 0)
+}
+
+// OpenFile open the file with flag and perm like os.OpenFile, and map the whole file.
+// os.O_RDONLY create a read only MAP_SHARED mapping,
+// os.O_RDWR create a read-write MAP_SHARED mapping, modifications are written back to the file,
+// O_PRIVATE create a copy-on-write MAP_PRIVATE mapping.
+func OpenFile(filename string, flag int, perm os.FileMode) (*File, error) {
+	prot, flags := syscall.PROT_READ, syscall.MAP_SHARED
+	switch {
+	case flag&O_PRIVATE != 0:
+		prot, flags = syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE
+	case flag&(os.O_RDONLY|os.O_WRONLY|os.O_RDWR) == os.O_RDWR:
+		prot = syscall.PROT_READ | syscall.PROT_WRITE
+	case flag&(os.O_RDONLY|os.O_WRONLY|os.O_RDWR) == os.O_WRONLY:
+		return nil, fmt.Errorf("mmap: file %q can not map write only", filename)
+	}
+
+	file, err := os.OpenFile(filename, flag&^O_PRIVATE, perm)
 	if nil != err {
 		return nil, err
 	}
 
-	fstat, err := file.Stat()
-	if nil != err {
+	f := &File{
+		file:  file,
+		prot:  prot,
+		flags: flags,
+	}
+	if err = f.mmap(); nil != err {
+		file.Close()
 		return nil, err
 	}
 
+	// help gc
+	runtime.SetFinalizer(f, (*File).Close)
+	return f, nil
+}
+
+// mmap map the whole file, empty file is not mapped.
+func (f *File) mmap() error {
+	fstat, err := f.file.Stat()
+	if nil != err {
+		return err
+	}
+
 	size := fstat.Size()
 	if size < 0 {
-		return nil, fmt.Errorf("mmap: file %q has negative size", file.Name())
+		return fmt.Errorf("mmap: file %q has negative size", f.file.Name())
 	}
 
 	if size != int64(int(size)) {
-		return nil, fmt.Errorf("mmap: file %q is too large", file.Name())
+		return fmt.Errorf("mmap: file %q is too large", f.file.Name())
 	}
 
-	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
-	if nil != err {
-		return nil, err
+	data := []byte{}
+	if size > 0 {
+		data, err = syscall.Mmap(int(f.file.Fd()), 0, int(size), f.prot, f.flags)
+		if nil != err {
+			return err
+		}
 	}
 
-	f := &File{
-		FileInfo: fstat,
-		data:     data,
-	}
+	f.FileInfo = fstat
+	f.data = data
+	f.mapped = data
+	return nil
+}
 
-	// help gc
-	runtime.SetFinalizer(f, (*File).Close)
-	return f, nil
+func (f *File) munmap() error {
+	mapped := f.mapped
+	f.data = nil
+	f.mapped = nil
+	if len(mapped) == 0 {
+		return nil
+	}
+	return syscall.Munmap(mapped)
 }
 
 func (f *File) Len() int {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
 	return len(f.data)
 }
 
-func (f *File) Close() error {
-	if nil == f || nil == f.data {
+// Writable report whether the mapping can be modified.
+func (f *File) Writable() bool {
+	return f.prot&syscall.PROT_WRITE != 0
+}
+
+// WriteAt write b to the mapping at off, it's not extend the mapping.
+func (f *File) WriteAt(b []byte, off int64) (int, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	if nil == f.data {
+		return 0, os.ErrClosed
+	}
+	if !f.Writable() {
+		return 0, ErrReadOnly
+	}
+	if off < 0 || int64(len(f.data)) < off {
+		return 0, fmt.Errorf("mmap: invalid offset %v", off)
+	}
+	var n int
+	if err := f.guard(func() { n = copy(f.data[off:], b) }); nil != err {
+		return 0, err
+	}
+	if n < len(b) {
+		return n, errors.New("mmap: write out of range")
+	}
+	return n, nil
+}
+
+// Flush write the modifications of the whole mapping back to file synchronously.
+func (f *File) Flush() error {
+	return f.FlushRange(0, f.Len(), Sync)
+}
+
+// FlushRange write the modifications of [off, off+n) back to file, it's useless for private mapping.
+func (f *File) FlushRange(off, n int, mode SyncMode) error {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	if nil == f.data {
+		return os.ErrClosed
+	}
+	if off < 0 || n < 0 || len(f.data) < off+n {
+		return fmt.Errorf("mmap: invalid flush range [%v, %v)", off, off+n)
+	}
+	if n == 0 {
 		return nil
 	}
+	// msync address must be page aligned.
+	off += len(f.mapped) - len(f.data)
+	pageOff := off % os.Getpagesize()
+	return msync(f.mapped[off-pageOff:off+n], int(mode))
+}
 
-	data := f.data
-	f.data = nil
+// Truncate change the file size and remap it, the previous mapped data is invalid.
+// When remap failed, the File is closed.
+func (f *File) Truncate(size int64) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if nil == f.data {
+		return os.ErrClosed
+	}
+	if f.region || nil == f.file {
+		return errors.New("mmap: can not truncate region or anonymous mapping")
+	}
+	if size < 0 || size != int64(int(size)) {
+		return fmt.Errorf("mmap: invalid size %v", size)
+	}
+	if err := f.munmap(); nil != err {
+		return err
+	}
+	err := f.file.Truncate(size)
+	if mmapErr := f.mmap(); nil != mmapErr {
+		runtime.SetFinalizer(f, nil)
+		f.file.Close()
+		return mmapErr
+	}
+	return err
+}
+
+func (f *File) Close() error {
+	if nil == f {
+		return nil
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if nil == f.data {
+		return nil
+	}
 
 	// help gc
 	runtime.SetFinalizer(f, nil)
-	return syscall.Munmap(data)
+	err := f.munmap()
+	if f.region || nil == f.file {
+		return err
+	}
+	if closeErr := f.file.Close(); nil == err {
+		err = closeErr
+	}
+	return err
 }
@@ -3,6 +3,8 @@ package mmap
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -21,3 +23,80 @@ func TestFile_Open(t *testing.T) {
 	}
 	fmt.Printf("ioutil data: \n%v \n", string(data))
 }
+
+func TestOpenFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmap")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "data")
+	if err = ioutil.WriteFile(filename, []byte("hello world"), 0644); nil != err {
+		t.Fatal(err)
+	}
+
+	// read only mapping.
+	file, err := OpenFile(filename, os.O_RDONLY, 0)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, err = file.WriteAt([]byte("x"), 0); err != ErrReadOnly {
+		t.Errorf("expected read only error, but %v", err)
+	}
+	file.Close()
+
+	// copy-on-write mapping, file not changed.
+	file, err = OpenFile(filename, os.O_RDONLY|O_PRIVATE, 0)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, err = file.WriteAt([]byte("HELLO"), 0); nil != err {
+		t.Error(err)
+	}
+	if string(file.data) != "HELLO world" {
+		t.Errorf("unexpected private mapping data %q", file.data)
+	}
+	file.Close()
+	if data, _ := ioutil.ReadFile(filename); string(data) != "hello world" {
+		t.Errorf("expected file not changed, but %q", data)
+	}
+
+	// read-write mapping, modifications written back.
+	file, err = OpenFile(filename, os.O_RDWR, 0)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err = file.WriteAt([]byte("WORLD"), 6); nil != err {
+		t.Error(err)
+	}
+	if _, err = file.WriteAt([]byte("!!"), 11); nil == err {
+		t.Error("expected out of range error")
+	}
+	if err = file.FlushRange(6, 5, Async); nil != err {
+		t.Error(err)
+	}
+	if err = file.Flush(); nil != err {
+		t.Error(err)
+	}
+	if data, _ := ioutil.ReadFile(filename); string(data) != "hello WORLD" {
+		t.Errorf("expected file changed, but %q", data)
+	}
+
+	// grow and shrink.
+	if err = file.Truncate(int64(os.Getpagesize()) + 1); nil != err {
+		t.Fatal(err)
+	}
+	if file.Len() != os.Getpagesize()+1 || file.FileInfo.Size() != int64(file.Len()) {
+		t.Errorf("unexpected length %v", file.Len())
+	}
+	if _, err = file.WriteAt([]byte("!"), int64(os.Getpagesize())); nil != err {
+		t.Error(err)
+	}
+	if err = file.Truncate(0); nil != err || file.Len() != 0 {
+		t.Errorf("unexpected truncate length %v, err: %v", file.Len(), err)
+	}
+	if err = file.Truncate(5); nil != err || string(file.data) != "\x00\x00\x00\x00\x00" {
+		t.Errorf("unexpected truncate data %q, err: %v", file.data, err)
+	}
+}
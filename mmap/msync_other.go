@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !freebsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!openbsd,!dragonfly
+
+package mmap
+
+import (
+	"errors"
+)
+
+func msync(b []byte, flags int) error {
+	return errors.New("mmap: msync is not supported on this platform")
+}
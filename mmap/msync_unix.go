@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd || openbsd || dragonfly
+// +build linux darwin freebsd openbsd dragonfly
+
+package mmap
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func msync(b []byte, flags int) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&b[0])), uintptr(len(b)), uintptr(flags))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package mmap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// writeToChunkSize limit the bytes written by once WriteTo call, so Close not wait too long.
+const writeToChunkSize = 1 << 20
+
+var errOffset = errors.New("mmap: invalid offset")
+
+// ReadAt implement io.ReaderAt, copy the mapped bytes at off into b.
+func (f *File) ReadAt(b []byte, off int64) (int, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	if nil == f.data {
+		return 0, os.ErrClosed
+	}
+	if off < 0 {
+		return 0, errOffset
+	}
+	if int64(len(f.data)) <= off {
+		return 0, io.EOF
+	}
+	var n int
+	if err := f.guard(func() { n = copy(b, f.data[off:]) }); nil != err {
+		return 0, err
+	}
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// At return the byte at index i.
+func (f *File) At(i int) (byte, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	if nil == f.data {
+		return 0, os.ErrClosed
+	}
+	if i < 0 || len(f.data) <= i {
+		return 0, fmt.Errorf("mmap: index %v out of range [0, %v)", i, len(f.data))
+	}
+	var b byte
+	err := f.guard(func() { b = f.data[i] })
+	return b, err
+}
+
+// Bytes return the mapped bytes without copy.
+// The returned slice is only valid until Close or Truncate, access it after that
+// will crash the process with SIGSEGV, so never retain it, and copy the bytes if need.
+// Never modify it when the mapping is read only.
+func (f *File) Bytes() []byte {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.data
+}
+
+// Section return a Reader that reads the mapping from off, and stops with EOF after n bytes.
+func (f *File) Section(off, n int64) *Reader {
+	return &Reader{f: f, base: off, off: off, limit: off + n}
+}
+
+// Reader read a section of the mapping, implement io.Reader, io.ReaderAt, io.Seeker and io.WriterTo.
+// It's not safe for concurrent use, except ReadAt.
+type Reader struct {
+	f     *File
+	base  int64
+	off   int64
+	limit int64
+}
+
+func (r *Reader) Read(b []byte) (n int, err error) {
+	if r.off >= r.limit {
+		return 0, io.EOF
+	}
+	if max := r.limit - r.off; int64(len(b)) > max {
+		b = b[:max]
+	}
+	n, err = r.f.ReadAt(b, r.off)
+	r.off += int64(n)
+	return
+}
+
+func (r *Reader) ReadAt(b []byte, off int64) (n int, err error) {
+	if off < 0 || off >= r.Size() {
+		return 0, io.EOF
+	}
+	off += r.base
+	if max := r.limit - off; int64(len(b)) > max {
+		b = b[:max]
+		n, err = r.f.ReadAt(b, off)
+		if nil == err {
+			err = io.EOF
+		}
+		return n, err
+	}
+	return r.f.ReadAt(b, off)
+}
+
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		offset += r.base
+	case io.SeekCurrent:
+		offset += r.off
+	case io.SeekEnd:
+		offset += r.limit
+	default:
+		return 0, errors.New("mmap: invalid whence")
+	}
+	if offset < r.base {
+		return 0, errOffset
+	}
+	r.off = offset
+	return offset - r.base, nil
+}
+
+// WriteTo write the remaining bytes to w from the mapping directly, without copy into a buffer.
+func (r *Reader) WriteTo(w io.Writer) (written int64, err error) {
+	for r.off < r.limit {
+		n, err := r.writeChunk(w)
+		written += int64(n)
+		r.off += int64(n)
+		if nil != err {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (r *Reader) writeChunk(w io.Writer) (int, error) {
+	r.f.mutex.RLock()
+	defer r.f.mutex.RUnlock()
+	if nil == r.f.data {
+		return 0, os.ErrClosed
+	}
+	end := r.limit
+	if end > int64(len(r.f.data)) {
+		end = int64(len(r.f.data))
+	}
+	if r.off >= end {
+		// the file is truncated.
+		return 0, io.ErrUnexpectedEOF
+	}
+	if end-r.off > writeToChunkSize {
+		end = r.off + writeToChunkSize
+	}
+	var n int
+	var err error
+	if faultErr := r.f.guard(func() { n, err = w.Write(r.f.data[r.off:end]) }); nil != faultErr {
+		return 0, faultErr
+	}
+	return n, err
+}
+
+// Size return the section size.
+func (r *Reader) Size() int64 {
+	return r.limit - r.base
+}
@@ -0,0 +1,78 @@
+package mmap
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFile_ReadAt(t *testing.T) {
+	filename := "mmap_test.go"
+	expected, err := ioutil.ReadFile(filename)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	file, err := Open(filename)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 16)
+	if n, err := file.ReadAt(buf, 8); nil != err || !bytes.Equal(buf[:n], expected[8:24]) {
+		t.Errorf("unexpected ReadAt %q, err: %v", buf[:n], err)
+	}
+	if n, err := file.ReadAt(buf, int64(len(expected)-4)); err != io.EOF || n != 4 {
+		t.Errorf("expected EOF after 4 bytes, but %v, err: %v", n, err)
+	}
+	if b, err := file.At(0); nil != err || b != expected[0] {
+		t.Errorf("unexpected At %v, err: %v", b, err)
+	}
+	if _, err := file.At(len(expected)); nil == err {
+		t.Error("expected out of range error")
+	}
+	if !bytes.Equal(file.Bytes(), expected) {
+		t.Error("unexpected Bytes")
+	}
+
+	// whole file reader.
+	if err = iotest.TestReader(file.Section(0, int64(file.Len())), expected); nil != err {
+		t.Error(err)
+	}
+
+	section := file.Section(8, 16)
+	var out bytes.Buffer
+	if n, err := section.WriteTo(&out); nil != err || n != 16 || !bytes.Equal(out.Bytes(), expected[8:24]) {
+		t.Errorf("unexpected WriteTo %q, err: %v", out.Bytes(), err)
+	}
+	if pos, err := section.Seek(-4, io.SeekEnd); nil != err || pos != 12 {
+		t.Errorf("unexpected Seek %v, err: %v", pos, err)
+	}
+	data, err := ioutil.ReadAll(section)
+	if nil != err || !bytes.Equal(data, expected[20:24]) {
+		t.Errorf("unexpected Read %q, err: %v", data, err)
+	}
+	if _, err = section.Seek(-1, io.SeekStart); nil == err {
+		t.Error("expected invalid offset error")
+	}
+
+	file.Close()
+	if _, err = file.ReadAt(buf, 0); err != os.ErrClosed {
+		t.Errorf("expected closed error, but %v", err)
+	}
+	if _, err = file.At(0); err != os.ErrClosed {
+		t.Errorf("expected closed error, but %v", err)
+	}
+	if _, err = file.Section(0, 10).Read(buf); err != os.ErrClosed {
+		t.Errorf("expected closed error, but %v", err)
+	}
+	if _, err = file.Section(0, 10).WriteTo(&out); err != os.ErrClosed {
+		t.Errorf("expected closed error, but %v", err)
+	}
+	if nil != file.Bytes() {
+		t.Error("expected nil Bytes after Close")
+	}
+}
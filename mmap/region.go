@@ -0,0 +1,168 @@
+package mmap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// MapRegion map length bytes of file from offset, the offset is not need to be page aligned.
+// prot is syscall.PROT_READ, or syscall.PROT_READ|syscall.PROT_WRITE when file is opened read-write.
+// The region must be in the file, and the returned File not own the file, Close not close it.
+func MapRegion(file *os.File, offset int64, length int, prot int) (*File, error) {
+	if offset < 0 || length <= 0 {
+		return nil, fmt.Errorf("mmap: invalid region [%v, %v)", offset, offset+int64(length))
+	}
+
+	fstat, err := file.Stat()
+	if nil != err {
+		return nil, err
+	}
+	if fstat.Size() < offset+int64(length) {
+		return nil, fmt.Errorf("mmap: region [%v, %v) out of file %q size %v",
+			offset, offset+int64(length), file.Name(), fstat.Size())
+	}
+
+	// mmap offset must be page aligned.
+	pageOff := int(offset % int64(os.Getpagesize()))
+	mapped, err := syscall.Mmap(int(file.Fd()), offset-int64(pageOff), length+pageOff, prot, syscall.MAP_SHARED)
+	if nil != err {
+		return nil, err
+	}
+
+	f := &File{
+		FileInfo: fstat,
+		data:     mapped[pageOff:],
+		mapped:   mapped,
+		file:     file,
+		prot:     prot,
+		flags:    syscall.MAP_SHARED,
+		region:   true,
+	}
+
+	// help gc
+	runtime.SetFinalizer(f, (*File).Close)
+	return f, nil
+}
+
+// WindowReader read a file by a sliding mapping window, it's remap when read out of the window,
+// so it can read the file larger than the address space.
+// It's not safe for concurrent use.
+type WindowReader struct {
+	file   *os.File
+	size   int64
+	window int
+
+	off       int64 // read position.
+	region    *File
+	regionOff int64
+}
+
+// NewWindowReader create a WindowReader of the file, window is the mapping size,
+// it's round up to page size, default 64 MB.
+func NewWindowReader(file *os.File, window int) (*WindowReader, error) {
+	fstat, err := file.Stat()
+	if nil != err {
+		return nil, err
+	}
+	if window <= 0 {
+		window = 64 << 20
+	}
+	pageSize := os.Getpagesize()
+	window = (window + pageSize - 1) / pageSize * pageSize
+
+	return &WindowReader{
+		file:   file,
+		size:   fstat.Size(),
+		window: window,
+	}, nil
+}
+
+// slide remap the window to cover the read position.
+func (r *WindowReader) slide() error {
+	if nil != r.region && r.regionOff <= r.off && r.off < r.regionOff+int64(r.region.Len()) {
+		return nil
+	}
+	if nil != r.region {
+		r.region.Close()
+		r.region = nil
+	}
+
+	regionOff := r.off / int64(r.window) * int64(r.window)
+	length := int64(r.window)
+	if r.size-regionOff < length {
+		length = r.size - regionOff
+	}
+	region, err := MapRegion(r.file, regionOff, int(length), syscall.PROT_READ)
+	if nil != err {
+		return err
+	}
+	r.region = region
+	r.regionOff = regionOff
+	return nil
+}
+
+// Next return the bytes from read position to the end of window without copy, and move the read position.
+// The returned bytes are only valid until the next call of WindowReader.
+func (r *WindowReader) Next() ([]byte, error) {
+	if r.off >= r.size {
+		return nil, io.EOF
+	}
+	if err := r.slide(); nil != err {
+		return nil, err
+	}
+	data := r.region.Bytes()[r.off-r.regionOff:]
+	r.off += int64(len(data))
+	return data, nil
+}
+
+func (r *WindowReader) Read(b []byte) (int, error) {
+	if r.off >= r.size {
+		return 0, io.EOF
+	}
+	if err := r.slide(); nil != err {
+		return 0, err
+	}
+	n, err := r.region.ReadAt(b, r.off-r.regionOff)
+	r.off += int64(n)
+	if err == io.EOF {
+		// end of window, not end of file.
+		err = nil
+	}
+	return n, err
+}
+
+func (r *WindowReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.off
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("mmap: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errOffset
+	}
+	r.off = offset
+	return offset, nil
+}
+
+// Size return the file size when WindowReader created.
+func (r *WindowReader) Size() int64 {
+	return r.size
+}
+
+// Close unmap the window, it's not close the file.
+func (r *WindowReader) Close() error {
+	if nil == r.region {
+		return nil
+	}
+	err := r.region.Close()
+	r.region = nil
+	return err
+}
@@ -0,0 +1,113 @@
+package mmap
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"testing/iotest"
+)
+
+func createTestFile(t *testing.T, size int) (string, []byte, func()) {
+	dir, err := ioutil.TempDir("", "mmap")
+	if nil != err {
+		t.Fatal(err)
+	}
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	filename := filepath.Join(dir, "data")
+	if err = ioutil.WriteFile(filename, data, 0644); nil != err {
+		t.Fatal(err)
+	}
+	return filename, data, func() { os.RemoveAll(dir) }
+}
+
+func TestMapRegion(t *testing.T) {
+	pageSize := os.Getpagesize()
+	filename, expected, cleanup := createTestFile(t, 3*pageSize)
+	defer cleanup()
+
+	file, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// not page aligned offset.
+	offset := int64(pageSize + 100)
+	region, err := MapRegion(file, offset, 200, syscall.PROT_READ|syscall.PROT_WRITE)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if region.Len() != 200 || !bytes.Equal(region.Bytes(), expected[offset:offset+200]) {
+		t.Errorf("unexpected region data")
+	}
+	if _, err = region.WriteAt([]byte("header"), 0); nil != err {
+		t.Error(err)
+	}
+	if err = region.FlushRange(0, 6, Sync); nil != err {
+		t.Error(err)
+	}
+	if err = region.Truncate(0); nil == err {
+		t.Error("expected region truncate error")
+	}
+	if err = region.Close(); nil != err {
+		t.Error(err)
+	}
+
+	// file is not closed by region.
+	header := make([]byte, 6)
+	if _, err = file.ReadAt(header, offset); nil != err || string(header) != "header" {
+		t.Errorf("unexpected header %q, err: %v", header, err)
+	}
+
+	if _, err = MapRegion(file, int64(2*pageSize), pageSize+1, syscall.PROT_READ); nil == err {
+		t.Error("expected out of file error")
+	}
+}
+
+func TestWindowReader(t *testing.T) {
+	pageSize := os.Getpagesize()
+	filename, expected, cleanup := createTestFile(t, 5*pageSize+123)
+	defer cleanup()
+
+	file, err := os.Open(filename)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader, err := NewWindowReader(file, 2*pageSize)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if err = iotest.TestReader(reader, expected); nil != err {
+		t.Error(err)
+	}
+
+	if _, err = reader.Seek(0, io.SeekStart); nil != err {
+		t.Fatal(err)
+	}
+	var scanned []byte
+	windows := 0
+	for {
+		data, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if nil != err {
+			t.Fatal(err)
+		}
+		windows++
+		scanned = append(scanned, data...)
+	}
+	if windows != 3 || !bytes.Equal(scanned, expected) {
+		t.Errorf("unexpected scan %v windows, %v bytes", windows, len(scanned))
+	}
+}
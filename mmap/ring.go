@@ -0,0 +1,152 @@
+package mmap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Ring is a single-producer single-consumer lock-free ring buffer of messages on a shared mapping,
+// the producer and consumer can be in different processes.
+//
+// The mapping layout, all fields are native endian uint64, and 64 bytes aligned to avoid false sharing:
+//
+//	offset   0: magic "GOLIBRNG", written at last when initialized.
+//	offset   8: capacity of data, power of two.
+//	offset  64: head, the total bytes written by producer, only producer update it.
+//	offset 128: tail, the total bytes read by consumer, only consumer update it.
+//	offset 192: data, messages are 4 bytes little endian length and payload, they may wrap around.
+//
+// The unread bytes are head-tail, the free bytes are capacity-(head-tail).
+type Ring struct {
+	file     *File
+	data     []byte
+	capacity uint64
+	head     *uint64
+	tail     *uint64
+}
+
+const (
+	RingHeaderSize = 192
+
+	ringMagic        = 0x474E5242494C4F47 // "GOLIBRNG" in little endian.
+	ringCapacityOff  = 8
+	ringHeadOff      = 64
+	ringTailOff      = 128
+	ringMsgHeaderLen = 4
+)
+
+var (
+	ErrRingFull  = errors.New("mmap: ring is full")
+	ErrRingEmpty = errors.New("mmap: ring is empty")
+)
+
+// NewRing use the writable mapping as a ring buffer, initialize the header when it's not initialized,
+// the capacity is the max power of two fit in the mapping.
+// The side who created the mapping should call NewRing before the other side map it.
+// The ring is only valid until the File is closed.
+func NewRing(f *File) (*Ring, error) {
+	if !f.Writable() {
+		return nil, ErrReadOnly
+	}
+	data := f.Bytes()
+	if len(data) < RingHeaderSize+ringMsgHeaderLen+1 {
+		return nil, fmt.Errorf("mmap: mapping size %v too small for ring", len(data))
+	}
+
+	magic := (*uint64)(unsafe.Pointer(&data[0]))
+	capacity := (*uint64)(unsafe.Pointer(&data[ringCapacityOff]))
+	r := &Ring{
+		file: f,
+		head: (*uint64)(unsafe.Pointer(&data[ringHeadOff])),
+		tail: (*uint64)(unsafe.Pointer(&data[ringTailOff])),
+	}
+
+	if atomic.LoadUint64(magic) != ringMagic {
+		c := uint64(1)
+		for c*2 <= uint64(len(data)-RingHeaderSize) {
+			c *= 2
+		}
+		atomic.StoreUint64(capacity, c)
+		atomic.StoreUint64(r.head, 0)
+		atomic.StoreUint64(r.tail, 0)
+		atomic.StoreUint64(magic, ringMagic)
+	}
+
+	r.capacity = atomic.LoadUint64(capacity)
+	if r.capacity&(r.capacity-1) != 0 || uint64(len(data)-RingHeaderSize) < r.capacity {
+		return nil, fmt.Errorf("mmap: invalid ring capacity %v", r.capacity)
+	}
+	r.data = data[RingHeaderSize : RingHeaderSize+int(r.capacity)]
+	return r, nil
+}
+
+// Capacity return the data capacity in bytes, every message take 4 more bytes.
+func (r *Ring) Capacity() int {
+	return int(r.capacity)
+}
+
+// Len return the unread bytes.
+func (r *Ring) Len() int {
+	return int(atomic.LoadUint64(r.head) - atomic.LoadUint64(r.tail))
+}
+
+// Write append a message, return ErrRingFull when there is no enough space. Only call it in producer.
+func (r *Ring) Write(msg []byte) error {
+	size := uint64(ringMsgHeaderLen + len(msg))
+	if size > r.capacity {
+		return fmt.Errorf("mmap: message size %v larger than ring capacity %v", len(msg), r.capacity)
+	}
+	head := atomic.LoadUint64(r.head)
+	if r.capacity-(head-atomic.LoadUint64(r.tail)) < size {
+		return ErrRingFull
+	}
+
+	var length [ringMsgHeaderLen]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(msg)))
+	r.copyIn(head, length[:])
+	r.copyIn(head+ringMsgHeaderLen, msg)
+
+	// publish the message after it's written.
+	atomic.StoreUint64(r.head, head+size)
+	return nil
+}
+
+// Read copy the next message into b, return ErrRingEmpty when there is no message,
+// io.ErrShortBuffer when b is too small, the message is kept. Only call it in consumer.
+func (r *Ring) Read(b []byte) (int, error) {
+	tail := atomic.LoadUint64(r.tail)
+	if atomic.LoadUint64(r.head) == tail {
+		return 0, ErrRingEmpty
+	}
+
+	var length [ringMsgHeaderLen]byte
+	r.copyOut(length[:], tail)
+	n := int(binary.LittleEndian.Uint32(length[:]))
+	if uint64(n) > r.capacity-ringMsgHeaderLen {
+		return 0, fmt.Errorf("mmap: ring is corrupted, message size %v", n)
+	}
+	if len(b) < n {
+		return 0, io.ErrShortBuffer
+	}
+	r.copyOut(b[:n], tail+ringMsgHeaderLen)
+
+	// release the space after it's read.
+	atomic.StoreUint64(r.tail, tail+ringMsgHeaderLen+uint64(n))
+	return n, nil
+}
+
+func (r *Ring) copyIn(pos uint64, b []byte) {
+	off := pos & (r.capacity - 1)
+	n := copy(r.data[off:], b)
+	copy(r.data, b[n:])
+}
+
+func (r *Ring) copyOut(b []byte, pos uint64) {
+	off := pos & (r.capacity - 1)
+	n := copy(b, r.data[off:])
+	copy(b[n:], r.data)
+}
@@ -0,0 +1,129 @@
+package mmap
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+)
+
+// Scanner iterate the records separated by a delimiter without copy, the last record
+// may not end with delimiter. It's not safe for concurrent use.
+//
+//	s := f.Lines()
+//	for s.Next() {
+//		process(s.Bytes())
+//	}
+type Scanner struct {
+	data   []byte
+	delim  byte
+	off    int
+	start  int
+	record []byte
+}
+
+// NewScanner return a Scanner of records separated by delim in data.
+// When delim is '\n', the trailing '\r' of every record is dropped like bufio.ScanLines.
+func NewScanner(data []byte, delim byte) *Scanner {
+	return &Scanner{data: data, delim: delim}
+}
+
+// Lines return a Scanner of lines in the mapping.
+// The scanned bytes are only valid until Close or Truncate.
+func (f *File) Lines() *Scanner {
+	return NewScanner(f.Bytes(), '\n')
+}
+
+// Records return a Scanner of records separated by delim in the mapping.
+// The scanned bytes are only valid until Close or Truncate.
+func (f *File) Records(delim byte) *Scanner {
+	return NewScanner(f.Bytes(), delim)
+}
+
+// Next move to the next record, return false at the end.
+func (s *Scanner) Next() bool {
+	if s.off >= len(s.data) {
+		s.record = nil
+		return false
+	}
+	s.start = s.off
+	i := bytes.IndexByte(s.data[s.off:], s.delim)
+	if i < 0 {
+		s.record = s.data[s.off:]
+		s.off = len(s.data)
+	} else {
+		s.record = s.data[s.off : s.off+i]
+		s.off += i + 1
+	}
+	if s.delim == '\n' && len(s.record) > 0 && s.record[len(s.record)-1] == '\r' {
+		s.record = s.record[:len(s.record)-1]
+	}
+	return true
+}
+
+// Bytes return the current record without delimiter, it's the bytes of mapping, never modify it.
+func (s *Scanner) Bytes() []byte {
+	return s.record
+}
+
+// Text return a copy of current record as string.
+func (s *Scanner) Text() string {
+	return string(s.record)
+}
+
+// Offset return the offset of current record in the scanned data.
+func (s *Scanner) Offset() int {
+	return s.start
+}
+
+// ParallelScan split the mapping into chunks aligned on newline boundaries,
+// and call fn with every chunk on workers goroutines, default runtime.NumCPU().
+// Every chunk contains whole lines, use NewScanner to iterate them.
+// It return the first error of fn after all workers finished.
+func (f *File) ParallelScan(workers int, fn func(chunk []byte) error) error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	chunks := splitLines(f.Bytes(), workers)
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(chunks))
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk []byte) {
+			defer wg.Done()
+			errs[i] = fn(chunk)
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitLines split data into at most n non-empty chunks, every chunk except the last ends with '\n'.
+func splitLines(data []byte, n int) [][]byte {
+	var chunks [][]byte
+	start := 0
+	for i := 1; i <= n && start < len(data); i++ {
+		end := len(data) * i / n
+		if end < start {
+			end = start
+		}
+		if i < n {
+			if j := bytes.IndexByte(data[end:], '\n'); j >= 0 {
+				end += j + 1
+			} else {
+				end = len(data)
+			}
+		}
+		if end > start {
+			chunks = append(chunks, data[start:end])
+		}
+		start = end
+	}
+	return chunks
+}
@@ -0,0 +1,193 @@
+package mmap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+const benchmarkLines = 100000
+
+// createLinesFile create a file of lines "line-<i>,<i*i>\n".
+func createLinesFile(tb testing.TB, lines int) (string, func()) {
+	dir, err := ioutil.TempDir("", "mmap-scan")
+	if nil != err {
+		tb.Fatal(err)
+	}
+	var buf bytes.Buffer
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&buf, "line-%v,%v\n", i, i*i)
+	}
+	filename := filepath.Join(dir, "lines")
+	if err = ioutil.WriteFile(filename, buf.Bytes(), 0644); nil != err {
+		tb.Fatal(err)
+	}
+	return filename, func() { os.RemoveAll(dir) }
+}
+
+func TestScanner(t *testing.T) {
+	s := NewScanner([]byte("a\r\nbb\n\nccc"), '\n')
+	var lines []string
+	var offsets []int
+	for s.Next() {
+		lines = append(lines, s.Text())
+		offsets = append(offsets, s.Offset())
+	}
+	if fmt.Sprint(lines) != "[a bb  ccc]" || fmt.Sprint(offsets) != "[0 3 6 7]" {
+		t.Errorf("unexpected lines %q at %v", lines, offsets)
+	}
+	if s.Next() || nil != s.Bytes() {
+		t.Error("expected end of scan")
+	}
+
+	s = NewScanner([]byte("a,b\r,c,"), ',')
+	lines = lines[:0]
+	for s.Next() {
+		lines = append(lines, s.Text())
+	}
+	if fmt.Sprintf("%q", lines) != `["a" "b\r" "c"]` {
+		t.Errorf("unexpected records %q", lines)
+	}
+}
+
+func TestFile_Lines(t *testing.T) {
+	filename, cleanup := createLinesFile(t, 1000)
+	defer cleanup()
+	f, err := Open(filename)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	count := 0
+	for s := f.Lines(); s.Next(); count++ {
+		if s.Text() != fmt.Sprintf("line-%v,%v", count, count*count) {
+			t.Fatalf("unexpected line %q", s.Bytes())
+		}
+	}
+	if count != 1000 {
+		t.Errorf("expected 1000 lines, but %v", count)
+	}
+
+	count = 0
+	for s := f.Records(','); s.Next(); count++ {
+	}
+	if count != 1001 {
+		t.Errorf("expected 1001 records, but %v", count)
+	}
+}
+
+func TestFile_ParallelScan(t *testing.T) {
+	filename, cleanup := createLinesFile(t, 1000)
+	defer cleanup()
+	f, err := Open(filename)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for _, workers := range []int{0, 1, 3, 7, 5000} {
+		var lines, chunks int64
+		err = f.ParallelScan(workers, func(chunk []byte) error {
+			atomic.AddInt64(&chunks, 1)
+			if chunk[len(chunk)-1] != '\n' {
+				t.Errorf("chunk not aligned on newline: %q", chunk)
+			}
+			for s := NewScanner(chunk, '\n'); s.Next(); {
+				atomic.AddInt64(&lines, 1)
+			}
+			return nil
+		})
+		if nil != err || lines != 1000 {
+			t.Errorf("unexpected %v lines of %v workers, err: %v", lines, workers, err)
+		}
+		if workers > 0 && chunks > int64(workers) {
+			t.Errorf("unexpected %v chunks of %v workers", chunks, workers)
+		}
+	}
+
+	expected := fmt.Errorf("failed")
+	if err = f.ParallelScan(4, func(chunk []byte) error { return expected }); err != expected {
+		t.Errorf("expected error, but %v", err)
+	}
+}
+
+func BenchmarkFile_Lines(b *testing.B) {
+	filename, cleanup := createLinesFile(b, benchmarkLines)
+	defer cleanup()
+	f, err := Open(filename)
+	if nil != err {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	b.SetBytes(int64(f.Len()))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		count := 0
+		for s := f.Lines(); s.Next(); {
+			count++
+		}
+		if count != benchmarkLines {
+			b.Fatalf("unexpected lines %v", count)
+		}
+	}
+}
+
+func BenchmarkFile_ParallelScan(b *testing.B) {
+	filename, cleanup := createLinesFile(b, benchmarkLines)
+	defer cleanup()
+	f, err := Open(filename)
+	if nil != err {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	b.SetBytes(int64(f.Len()))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var count int64
+		f.ParallelScan(0, func(chunk []byte) error {
+			n := int64(0)
+			for s := NewScanner(chunk, '\n'); s.Next(); {
+				n++
+			}
+			atomic.AddInt64(&count, n)
+			return nil
+		})
+		if count != benchmarkLines {
+			b.Fatalf("unexpected lines %v", count)
+		}
+	}
+}
+
+func BenchmarkBufioScanner(b *testing.B) {
+	filename, cleanup := createLinesFile(b, benchmarkLines)
+	defer cleanup()
+	fstat, err := os.Stat(filename)
+	if nil != err {
+		b.Fatal(err)
+	}
+	b.SetBytes(fstat.Size())
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		file, err := os.Open(filename)
+		if nil != err {
+			b.Fatal(err)
+		}
+		count := 0
+		for s := bufio.NewScanner(file); s.Scan(); {
+			count++
+		}
+		file.Close()
+		if count != benchmarkLines {
+			b.Fatalf("unexpected lines %v", count)
+		}
+	}
+}
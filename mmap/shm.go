@@ -0,0 +1,77 @@
+package mmap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+// ShmDir is the directory of named shared memory, it's tmpfs on linux.
+var ShmDir = "/dev/shm"
+
+// Anonymous create a zero filled anonymous mapping of size bytes, it's not backed by any file.
+// The shared mapping is shared with the child processes created by fork(2),
+// the private one is copy-on-write after fork.
+func Anonymous(size int, shared bool) (*File, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("mmap: invalid anonymous mapping size %v", size)
+	}
+	flags := syscall.MAP_ANON | syscall.MAP_PRIVATE
+	if shared {
+		flags = syscall.MAP_ANON | syscall.MAP_SHARED
+	}
+	prot := syscall.PROT_READ | syscall.PROT_WRITE
+	data, err := syscall.Mmap(-1, 0, size, prot, flags)
+	if nil != err {
+		return nil, err
+	}
+
+	f := &File{
+		data:   data,
+		mapped: data,
+		prot:   prot,
+		flags:  flags,
+	}
+
+	// help gc
+	runtime.SetFinalizer(f, (*File).Close)
+	return f, nil
+}
+
+// OpenShm open the named shared memory under ShmDir with flag and perm like OpenFile,
+// cooperating processes map the same name to exchange data without copy.
+// When flag contains os.O_CREATE, it's extended to size bytes if smaller.
+func OpenShm(name string, flag int, perm os.FileMode, size int64) (*File, error) {
+	if name == "" || strings.ContainsRune(name, '/') {
+		return nil, fmt.Errorf("mmap: invalid shared memory name %q", name)
+	}
+	filename := filepath.Join(ShmDir, name)
+
+	if flag&os.O_CREATE != 0 {
+		file, err := os.OpenFile(filename, flag&^O_PRIVATE, perm)
+		if nil != err {
+			return nil, err
+		}
+		fstat, err := file.Stat()
+		if nil == err && fstat.Size() < size {
+			err = file.Truncate(size)
+		}
+		file.Close()
+		if nil != err {
+			return nil, err
+		}
+		flag &^= os.O_CREATE | os.O_EXCL | os.O_TRUNC
+	}
+	return OpenFile(filename, flag, perm)
+}
+
+// RemoveShm remove the named shared memory, the mapped processes are not affected.
+func RemoveShm(name string) error {
+	if name == "" || strings.ContainsRune(name, '/') {
+		return fmt.Errorf("mmap: invalid shared memory name %q", name)
+	}
+	return os.Remove(filepath.Join(ShmDir, name))
+}
@@ -0,0 +1,175 @@
+package mmap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	ringHelperEnv      = "GOLIB_MMAP_RING_HELPER"
+	ringHelperMessages = 10000
+)
+
+func TestAnonymous(t *testing.T) {
+	for _, shared := range []bool{false, true} {
+		file, err := Anonymous(100, shared)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if file.Len() != 100 || !bytes.Equal(file.Bytes(), make([]byte, 100)) {
+			t.Error("expected zero filled mapping")
+		}
+		if _, err = file.WriteAt([]byte("hello"), 10); nil != err {
+			t.Error(err)
+		}
+		if stale, err := file.Stale(); nil != err || stale {
+			t.Errorf("expected not stale, but %v, err: %v", stale, err)
+		}
+		if err = file.Truncate(10); nil == err {
+			t.Error("expected truncate error")
+		}
+		if err = file.Close(); nil != err {
+			t.Error(err)
+		}
+	}
+	if _, err := Anonymous(0, true); nil == err {
+		t.Error("expected invalid size error")
+	}
+}
+
+func TestRing(t *testing.T) {
+	file, err := Anonymous(RingHeaderSize+64, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	ring, err := NewRing(file)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if ring.Capacity() != 64 {
+		t.Errorf("unexpected capacity %v", ring.Capacity())
+	}
+
+	buf := make([]byte, 64)
+	if _, err = ring.Read(buf); err != ErrRingEmpty {
+		t.Errorf("expected empty error, but %v", err)
+	}
+	// messages wrap around the end of data.
+	for i := 0; i < 100; i++ {
+		msg := []byte(fmt.Sprintf("message-%v", i))
+		if err = ring.Write(msg); nil != err {
+			t.Fatal(err)
+		}
+		if i%2 == 0 {
+			// the unread message take at least 13 bytes, no space for 4+50 bytes.
+			if err = ring.Write(make([]byte, 50)); err != ErrRingFull {
+				t.Fatalf("expected full error, but %v", err)
+			}
+		}
+		if _, err = ring.Read(buf[:3]); err != io.ErrShortBuffer {
+			t.Fatalf("expected short buffer error, but %v", err)
+		}
+		n, err := ring.Read(buf)
+		if nil != err || !bytes.Equal(buf[:n], msg) {
+			t.Fatalf("unexpected message %q, err: %v", buf[:n], err)
+		}
+	}
+
+	// initialized ring keep it's data.
+	ring.Write([]byte("kept"))
+	ring, err = NewRing(file)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if n, err := ring.Read(buf); nil != err || string(buf[:n]) != "kept" {
+		t.Errorf("unexpected message %q, err: %v", buf[:n], err)
+	}
+}
+
+// TestRingHelperProcess is the producer process of TestRing_SharedMemory.
+func TestRingHelperProcess(t *testing.T) {
+	name := os.Getenv(ringHelperEnv)
+	if name == "" {
+		return
+	}
+	file, err := OpenShm(name, os.O_RDWR, 0, 0)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	ring, err := NewRing(file)
+	if nil != err {
+		t.Fatal(err)
+	}
+	for i := 0; i < ringHelperMessages; {
+		err = ring.Write([]byte(strconv.Itoa(i)))
+		if err == ErrRingFull {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		if nil != err {
+			t.Fatal(err)
+		}
+		i++
+	}
+}
+
+func TestRing_SharedMemory(t *testing.T) {
+	if _, err := os.Stat(ShmDir); nil != err {
+		t.Skip(err)
+	}
+	name := fmt.Sprintf("golib-mmap-test-%v", os.Getpid())
+	file, err := OpenShm(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600, 4096)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer RemoveShm(name)
+	defer file.Close()
+	ring, err := NewRing(file)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	// the helper is killed when the test is done or timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(10)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=^TestRingHelperProcess$")
+	cmd.Env = append(os.Environ(), ringHelperEnv+"="+name)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err = cmd.Start(); nil != err {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 16)
+	for i := 0; i < ringHelperMessages; {
+		n, err := ring.Read(buf)
+		if err == ErrRingEmpty {
+			if nil != ctx.Err() {
+				t.Fatalf("timeout after %v messages read", i)
+			}
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		if nil != err || string(buf[:n]) != strconv.Itoa(i) {
+			t.Fatalf("expected message %v, but %q, err: %v", i, buf[:n], err)
+		}
+		i++
+	}
+	if err = cmd.Wait(); nil != err {
+		t.Fatalf("helper process failed: %v\n%s", err, output.String())
+	}
+	if ring.Len() != 0 {
+		t.Errorf("expected all messages read, but %v bytes left", ring.Len())
+	}
+}
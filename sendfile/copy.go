@@ -0,0 +1,121 @@
+package sendfile
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// CopyOptions is the options of CopyFile.
+type CopyOptions struct {
+	PreserveModTime bool // set the mtime of dst to src's.
+}
+
+// CopyFile copy the regular file src to dst, dst is created or truncated, with the permissions of src.
+// It try FICLONE reflink first, then copy_file_range(2), sendfile(2), and a buffered copy at last,
+// return the bytes copied and the last method used.
+func CopyFile(dst, src string, options *CopyOptions) (int64, Method, error) {
+	srcFile, err := os.Open(src)
+	if nil != err {
+		return 0, MethodCopy, err
+	}
+	defer srcFile.Close()
+	fstat, err := srcFile.Stat()
+	if nil != err {
+		return 0, MethodCopy, err
+	}
+	if !fstat.Mode().IsRegular() {
+		return 0, MethodCopy, fmt.Errorf("not a regular file, filename: %v", src)
+	}
+	// truncate dst would empty src when they are the same file.
+	if dstStat, err := os.Stat(dst); nil == err && os.SameFile(fstat, dstStat) {
+		return 0, MethodCopy, fmt.Errorf("copy to the same file, filename: %v, %v", src, dst)
+	}
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fstat.Mode().Perm())
+	if nil != err {
+		return 0, MethodCopy, err
+	}
+	defer dstFile.Close()
+	// the existed file keep it's permissions, and umask is applied on the created one.
+	if err = dstFile.Chmod(fstat.Mode().Perm()); nil != err {
+		return 0, MethodCopy, err
+	}
+
+	size := fstat.Size()
+	var n int64
+	method := MethodReflink
+	if err = reflink(dstFile, srcFile); nil == err {
+		n = size
+	} else if isCopyUnsupported(err) {
+		n, method, err = CopyRange(dstFile, srcFile, 0, 0, size)
+	}
+	if nil == err {
+		err = dstFile.Close()
+	}
+	if nil == err && nil != options && options.PreserveModTime {
+		err = os.Chtimes(dst, fstat.ModTime(), fstat.ModTime())
+	}
+	return n, method, err
+}
+
+// CopyRange copy length bytes of src from srcOffset to dst at dstOffset.
+// It try FICLONERANGE reflink first, then copy_file_range(2), sendfile(2), and a buffered copy at last,
+// return the bytes copied and the last method used.
+// The offset of src is never changed, but the offset of dst may be changed by sendfile.
+// It return io.ErrUnexpectedEOF when src ends before length bytes copied.
+func CopyRange(dst, src *os.File, dstOffset, srcOffset, length int64) (int64, Method, error) {
+	if dstOffset < 0 || srcOffset < 0 || length < 0 {
+		return 0, MethodCopy, fmt.Errorf("invalid range, offset: %v, %v, length: %v, filename: %v", dstOffset, srcOffset, length, src.Name())
+	}
+	if length == 0 {
+		return 0, MethodCopy, nil
+	}
+
+	err := reflinkRange(dst, src, dstOffset, srcOffset, length)
+	if nil == err {
+		return length, MethodReflink, nil
+	}
+	n, err := copyFileRange(dst, src, dstOffset, srcOffset, length)
+	if !isCopyUnsupported(err) {
+		return n, MethodCopyFileRange, err
+	}
+	m, err := sendfileFile(dst, src, dstOffset+n, srcOffset+n, length-n)
+	n += m
+	if !isCopyUnsupported(err) {
+		return n, MethodSendfile, err
+	}
+	m, err = bufferedCopy(dst, src, dstOffset+n, srcOffset+n, length-n)
+	return n + m, MethodCopy, err
+}
+
+func bufferedCopy(dst, src *os.File, dstOffset, srcOffset, length int64) (int64, error) {
+	buf := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(buf)
+
+	var written int64
+	for written < length {
+		b := *buf
+		if int64(len(b)) > length-written {
+			b = b[:length-written]
+		}
+		n, err := src.ReadAt(b, srcOffset+written)
+		if n > 0 {
+			m, werr := dst.WriteAt(b[:n], dstOffset+written)
+			written += int64(m)
+			if nil != werr {
+				return written, werr
+			}
+		}
+		if err == io.EOF {
+			if written < length {
+				return written, io.ErrUnexpectedEOF
+			}
+			break
+		}
+		if nil != err {
+			return written, err
+		}
+	}
+	return written, nil
+}
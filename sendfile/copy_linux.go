@@ -0,0 +1,139 @@
+package sendfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	ficlone      = 0x40049409
+	ficlonerange = 0x4020940d
+)
+
+// fileCloneRange is struct file_clone_range of FICLONERANGE.
+type fileCloneRange struct {
+	srcFd      int64
+	srcOffset  uint64
+	srcLength  uint64
+	destOffset uint64
+}
+
+// control call fn with the fds of both files.
+func control(dst, src *os.File, fn func(dfd, sfd uintptr) error) error {
+	dstRaw, err := dst.SyscallConn()
+	if nil != err {
+		return err
+	}
+	srcRaw, err := src.SyscallConn()
+	if nil != err {
+		return err
+	}
+	var fnErr error
+	err = dstRaw.Control(func(dfd uintptr) {
+		err := srcRaw.Control(func(sfd uintptr) {
+			fnErr = fn(dfd, sfd)
+		})
+		if nil == fnErr {
+			fnErr = err
+		}
+	})
+	if nil == err {
+		err = fnErr
+	}
+	return err
+}
+
+// reflink share all the extents of src with dst, dst is replaced.
+func reflink(dst, src *os.File) error {
+	return control(dst, src, func(dfd, sfd uintptr) error {
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dfd, ficlone, sfd); errno != 0 {
+			return os.NewSyscallError("ioctl", errno)
+		}
+		return nil
+	})
+}
+
+// reflinkRange share the extents of range, the offsets and length must be aligned to the block size.
+func reflinkRange(dst, src *os.File, dstOffset, srcOffset, length int64) error {
+	return control(dst, src, func(dfd, sfd uintptr) error {
+		arg := fileCloneRange{
+			srcFd:      int64(sfd),
+			srcOffset:  uint64(srcOffset),
+			srcLength:  uint64(length),
+			destOffset: uint64(dstOffset),
+		}
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dfd, ficlonerange, uintptr(unsafe.Pointer(&arg)))
+		if errno != 0 {
+			return os.NewSyscallError("ioctl", errno)
+		}
+		return nil
+	})
+}
+
+func copyFileRange(dst, src *os.File, dstOffset, srcOffset, length int64) (int64, error) {
+	if sysCopyFileRange < 0 {
+		return 0, os.NewSyscallError("copy_file_range", syscall.ENOSYS)
+	}
+	trap := sysCopyFileRange
+	var written int64
+	err := control(dst, src, func(dfd, sfd uintptr) error {
+		for written < length {
+			n := length - written
+			if n > maxSendfileChunk {
+				n = maxSendfileChunk
+			}
+			roff, woff := srcOffset+written, dstOffset+written
+			r, _, errno := syscall.Syscall6(uintptr(trap), sfd, uintptr(unsafe.Pointer(&roff)),
+				dfd, uintptr(unsafe.Pointer(&woff)), uintptr(n), 0)
+			switch {
+			case errno == syscall.EINTR:
+				continue
+			case errno != 0:
+				return os.NewSyscallError("copy_file_range", errno)
+			case r == 0:
+				return io.ErrUnexpectedEOF
+			}
+			written += int64(r)
+		}
+		return nil
+	})
+	return written, err
+}
+
+// sendfileFile copy by sendfile(2) to a regular file, the offset of dst is moved.
+func sendfileFile(dst, src *os.File, dstOffset, srcOffset, length int64) (int64, error) {
+	if _, err := dst.Seek(dstOffset, io.SeekStart); nil != err {
+		return 0, err
+	}
+	var written int64
+	err := control(dst, src, func(dfd, sfd uintptr) error {
+		for written < length {
+			n := length - written
+			if n > maxSendfileChunk {
+				n = maxSendfileChunk
+			}
+			off := srcOffset + written
+			m, err := syscall.Sendfile(int(dfd), int(sfd), &off, int(n))
+			switch {
+			case err == syscall.EINTR:
+				continue
+			case nil != err:
+				return os.NewSyscallError("sendfile", err)
+			case m == 0:
+				return io.ErrUnexpectedEOF
+			}
+			written += int64(m)
+		}
+		return nil
+	})
+	return written, err
+}
+
+// isCopyUnsupported check the error is caused by the file system or kernel, so the next method should be tried.
+func isCopyUnsupported(err error) bool {
+	return isSendfileUnsupported(err) || errors.Is(err, syscall.EXDEV) || errors.Is(err, syscall.ENOTTY) ||
+		errors.Is(err, syscall.EBADF) || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,4 @@
+package sendfile
+
+// sysCopyFileRange is the syscall number of copy_file_range(2), it's missing in package syscall.
+const sysCopyFileRange = 391
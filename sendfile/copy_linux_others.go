@@ -0,0 +1,7 @@
+//go:build linux && !amd64 && !arm64 && !386 && !arm
+// +build linux,!amd64,!arm64,!386,!arm
+
+package sendfile
+
+// sysCopyFileRange is unknown on this arch, copy_file_range(2) is skipped.
+const sysCopyFileRange = -1
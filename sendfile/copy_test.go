@@ -0,0 +1,107 @@
+package sendfile
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopyFile(t *testing.T) {
+	file, data := createRangeFile(t, 3<<20)
+	defer os.Remove(file.Name())
+	file.Close()
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(file.Name(), modTime, modTime)
+	os.Chmod(file.Name(), 0640)
+
+	dir, err := ioutil.TempDir("", "sendfile-copy")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dst := filepath.Join(dir, "copied")
+	// the existed file is truncated.
+	ioutil.WriteFile(dst, make([]byte, 4<<20), 0600)
+
+	n, method, err := CopyFile(dst, file.Name(), &CopyOptions{PreserveModTime: true})
+	if nil != err || n != int64(len(data)) {
+		t.Fatalf("unexpected copied %v by %v, err: %v", n, method, err)
+	}
+	t.Logf("copied by %v", method)
+	copied, _ := ioutil.ReadFile(dst)
+	if !bytes.Equal(copied, data) {
+		t.Errorf("unexpected copied content of %v bytes", len(copied))
+	}
+	fstat, err := os.Stat(dst)
+	if nil != err || fstat.Mode().Perm() != 0640 || !fstat.ModTime().Equal(modTime) {
+		t.Errorf("unexpected mode %v, mtime %v, err: %v", fstat.Mode(), fstat.ModTime(), err)
+	}
+
+	if _, _, err = CopyFile(dst, dir, nil); nil == err {
+		t.Error("expected not regular file error")
+	}
+
+	// the same file, by a hard link.
+	link := filepath.Join(dir, "link")
+	if err = os.Link(dst, link); nil != err {
+		t.Fatal(err)
+	}
+	for _, name := range []string{dst, link} {
+		if _, _, err = CopyFile(name, dst, nil); nil == err {
+			t.Errorf("expected same file error of %v", name)
+		}
+	}
+	if copied, _ = ioutil.ReadFile(dst); !bytes.Equal(copied, data) {
+		t.Errorf("expected the file not changed, but %v bytes", len(copied))
+	}
+}
+
+func TestCopyRange(t *testing.T) {
+	src, data := createRangeFile(t, 1<<20)
+	defer os.Remove(src.Name())
+	defer src.Close()
+	dst, err := ioutil.TempFile("", "sendfile-copy")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.Remove(dst.Name())
+	defer dst.Close()
+
+	n, method, err := CopyRange(dst, src, 100, 1000, 5000)
+	if nil != err || n != 5000 {
+		t.Fatalf("unexpected copied %v by %v, err: %v", n, method, err)
+	}
+	copied, _ := ioutil.ReadFile(dst.Name())
+	if len(copied) != 5100 || !bytes.Equal(copied[100:], data[1000:6000]) {
+		t.Errorf("unexpected copied content of %v bytes", len(copied))
+	}
+	if offset, _ := src.Seek(0, io.SeekCurrent); offset != int64(len(data)) {
+		t.Errorf("expected src offset unchanged, but %v", offset)
+	}
+
+	n, _, err = CopyRange(dst, src, 0, 1<<20-10, 20)
+	if err != io.ErrUnexpectedEOF || n != 10 {
+		t.Errorf("expected unexpected EOF after 10 bytes, but %v, err: %v", n, err)
+	}
+
+	// sendfile is unsupported on some platforms.
+	if n, err = sendfileFile(dst, src, 0, 20, 1000); !isCopyUnsupported(err) {
+		copied, _ = ioutil.ReadFile(dst.Name())
+		if nil != err || n != 1000 || !bytes.Equal(copied[:1000], data[20:1020]) {
+			t.Errorf("unexpected sendfile copied %v, err: %v", n, err)
+		}
+	}
+
+	n, err = bufferedCopy(dst, src, 0, 10, 1<<20-10)
+	copied, _ = ioutil.ReadFile(dst.Name())
+	if nil != err || n != 1<<20-10 || !bytes.Equal(copied, data[10:]) {
+		t.Errorf("unexpected buffered copied %v, err: %v", n, err)
+	}
+	if n, err = bufferedCopy(dst, src, 0, 10, 1<<20); err != io.ErrUnexpectedEOF || n != 1<<20-10 {
+		t.Errorf("expected unexpected EOF, but %v, err: %v", n, err)
+	}
+}
@@ -0,0 +1,33 @@
+package sendfile
+
+import (
+	"net"
+	"os"
+)
+
+// SendfileWithHeaders send the headers, length bytes of file from offset, and the trailers in one batch,
+// like the sf_hdtr of BSD sendfile(2), so the small header doesn't go in a separate packet.
+// On linux the TCP connection is corked by TCP_CORK until all are written, otherwise the headers
+// and trailers are written by writev(2). It return the total bytes written and the method of file.
+func SendfileWithHeaders(conn net.Conn, headers [][]byte, file *os.File, offset, length int64, trailers [][]byte) (int64, Method, error) {
+	if tcpConn, ok := unwrapConn(conn).(*net.TCPConn); ok {
+		if err := setCork(tcpConn, true); nil == err {
+			defer setCork(tcpConn, false)
+		}
+	}
+
+	// copy the slice, WriteTo consumes it.
+	buffers := append(net.Buffers(nil), headers...)
+	written, err := buffers.WriteTo(conn)
+	if nil != err {
+		return written, MethodSendfile, err
+	}
+	n, method, err := SendfileConn(conn, file, offset, length)
+	written += n
+	if nil != err {
+		return written, method, err
+	}
+	buffers = append(net.Buffers(nil), trailers...)
+	n, err = buffers.WriteTo(conn)
+	return written + n, method, err
+}
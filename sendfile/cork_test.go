@@ -0,0 +1,46 @@
+package sendfile
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"testing"
+)
+
+func TestSendfileWithHeaders(t *testing.T) {
+	file, data := createRangeFile(t, 10000)
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	headers := [][]byte{[]byte("HTTP/1.1 200 OK\r\n"), []byte("Content-Length: 100\r\n\r\n")}
+	trailers := [][]byte{[]byte("\r\n--end--\r\n")}
+	expected := append(append(append([]byte{}, bytes.Join(headers, nil)...), data[500:600]...), trailers[0]...)
+
+	received, n, err := sendAndReceive(t, func(conn *net.TCPConn) (int64, error) {
+		n, method, err := SendfileWithHeaders(conn, headers, file, 500, 100, trailers)
+		if method != MethodSendfile {
+			t.Errorf("expected sendfile, but %v", method)
+		}
+		return n, err
+	}, 0)
+	if nil != err || n != int64(len(expected)) || !bytes.Equal(received, expected) {
+		t.Errorf("unexpected sent %v, received %q, err: %v", n, received, err)
+	}
+	if len(headers) != 2 || len(trailers) != 1 {
+		t.Error("expected headers and trailers unchanged")
+	}
+
+	// the connection without fd.
+	server, client := net.Pipe()
+	done := make(chan []byte)
+	go func() {
+		var buf bytes.Buffer
+		buf.ReadFrom(client)
+		done <- buf.Bytes()
+	}()
+	n, method, err := SendfileWithHeaders(server, headers, file, 500, 100, trailers)
+	server.Close()
+	if received = <-done; nil != err || method != MethodCopy || !bytes.Equal(received, expected) {
+		t.Errorf("unexpected sent %v by %v, err: %v", n, method, err)
+	}
+}
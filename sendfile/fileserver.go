@@ -0,0 +1,359 @@
+package sendfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileHandler is a http.Handler serves the files under Root with zero-copy.
+//
+// It supports Range and multi-range requests, the conditional requests of If-Match, If-None-Match,
+// If-Modified-Since, If-Unmodified-Since and If-Range, with strong ETags of inode, mtime and size.
+// When the response body is not smaller than ZeroCopyMinSize, the file is seeked and copied by
+// io.LimitReader, the ReadFrom of http.ResponseWriter send it by sendfile on the plain HTTP/1.x connection,
+// the keep-alive and the WriteTimeout of http.Server are kept. Otherwise the file is copied by ReadAt,
+// the file offset is never used.
+// The connection is not hijacked to call SendfileRange: the hijacked connection has no deadline and
+// can't be kept alive, while the standard response path already unwraps the *net.TCPConn for sendfile.
+type FileHandler struct {
+	Root string
+
+	// ZeroCopyMinSize is the min body size to send by sendfile, default 1 MB, negative disable it.
+	ZeroCopyMinSize int64
+}
+
+// bodyPart is a part of response body, the header bytes followed by the file range.
+type bodyPart struct {
+	header []byte
+	offset int64
+	length int64
+}
+
+const (
+	defaultZeroCopyMinSize = 1 << 20
+	sniffLen               = 512
+	indexPage              = "index.html"
+)
+
+var errUnsatisfiableRange = errors.New("invalid range")
+
+// FileServer return a FileHandler serves the files under root.
+func FileServer(root string) *FileHandler {
+	return &FileHandler{Root: root}
+}
+
+func (h *FileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// the cleaned path never go out of root.
+	name := filepath.Join(h.Root, filepath.FromSlash(path.Clean("/"+r.URL.Path)))
+	opened, err := os.Open(name)
+	if nil != err {
+		serveError(w, err)
+		return
+	}
+	defer opened.Close()
+	file := opened
+
+	fstat, err := file.Stat()
+	if nil != err {
+		serveError(w, err)
+		return
+	}
+	if fstat.IsDir() {
+		index, err := os.Open(filepath.Join(name, indexPage))
+		if nil != err {
+			serveError(w, err)
+			return
+		}
+		defer index.Close()
+		file = index
+	}
+	h.ServeFile(w, r, file)
+}
+
+func serveError(w http.ResponseWriter, err error) {
+	switch {
+	case os.IsNotExist(err):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case os.IsPermission(err):
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// ServeFile serve the opened regular file, the file is not closed.
+// The Content-Type is detected by the file name extension, or sniffed from content,
+// if it's not set in the header of w.
+// The file offset is changed when sent by sendfile, don't share the file by concurrent requests.
+func (h *FileHandler) ServeFile(w http.ResponseWriter, r *http.Request, file *os.File) {
+	fstat, err := file.Stat()
+	if nil != err {
+		serveError(w, err)
+		return
+	}
+	if !fstat.Mode().IsRegular() {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	size := fstat.Size()
+	modtime := fstat.ModTime()
+	etag := fileETag(fstat)
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Last-Modified", modtime.UTC().Format(http.TimeFormat))
+	header.Set("Accept-Ranges", "bytes")
+
+	switch checkPreconditions(r, etag, modtime) {
+	case http.StatusNotModified:
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	case http.StatusPreconditionFailed:
+		http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+		return
+	}
+
+	ctype := header.Get("Content-Type")
+	if ctype == "" {
+		if ctype, err = contentType(file); nil != err {
+			serveError(w, err)
+			return
+		}
+		header.Set("Content-Type", ctype)
+	}
+
+	var ranges []bodyPart
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" && checkIfRange(r, etag, modtime) {
+		if ranges, err = parseRange(rangeHeader, size); nil != err {
+			header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		var total int64
+		for _, ra := range ranges {
+			total += ra.length
+		}
+		// the overlapped ranges are larger than file, send the whole file is cheaper.
+		if total > size {
+			ranges = nil
+		}
+	}
+
+	status := http.StatusOK
+	var parts []bodyPart
+	switch len(ranges) {
+	case 0:
+		parts = []bodyPart{{offset: 0, length: size}}
+	case 1:
+		status = http.StatusPartialContent
+		header.Set("Content-Range", contentRange(ranges[0], size))
+		parts = ranges
+	default:
+		status = http.StatusPartialContent
+		boundary := multipart.NewWriter(ioutil.Discard).Boundary()
+		header.Set("Content-Type", "multipart/byteranges; boundary="+boundary)
+		for i, ra := range ranges {
+			var partHeader strings.Builder
+			if i > 0 {
+				partHeader.WriteString("\r\n")
+			}
+			fmt.Fprintf(&partHeader, "--%s\r\nContent-Type: %s\r\nContent-Range: %s\r\n\r\n", boundary, ctype, contentRange(ra, size))
+			ra.header = []byte(partHeader.String())
+			parts = append(parts, ra)
+		}
+		parts = append(parts, bodyPart{header: []byte("\r\n--" + boundary + "--\r\n")})
+	}
+
+	var length int64
+	for _, part := range parts {
+		length += int64(len(part.header)) + part.length
+	}
+	header.Set("Content-Length", strconv.FormatInt(length, 10))
+
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+	zeroCopy := h.zeroCopy(r, length)
+	w.WriteHeader(status)
+	for _, part := range parts {
+		if _, err = w.Write(part.header); nil != err {
+			return
+		}
+		if part.length == 0 {
+			continue
+		}
+		var body io.Reader = io.NewSectionReader(file, part.offset, part.length)
+		if zeroCopy {
+			// the ReadFrom of ResponseWriter send the *io.LimitedReader of *os.File by sendfile.
+			if _, err = file.Seek(part.offset, io.SeekStart); nil != err {
+				return
+			}
+			body = io.LimitReader(file, part.length)
+		}
+		if _, err = io.Copy(w, body); nil != err {
+			return
+		}
+	}
+}
+
+func (h *FileHandler) zeroCopy(r *http.Request, length int64) bool {
+	minSize := h.ZeroCopyMinSize
+	if minSize == 0 {
+		minSize = defaultZeroCopyMinSize
+	}
+	// sendfile can't write to TLS and HTTP/2 streams.
+	return minSize > 0 && length >= minSize && nil == r.TLS && r.ProtoMajor == 1
+}
+
+// fileETag return the strong ETag of inode, mtime and size.
+func fileETag(fstat os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x-%x"`, fileInode(fstat), fstat.ModTime().UnixNano(), fstat.Size())
+}
+
+// contentType detect the type by file name extension, or sniff the first 512 bytes.
+func contentType(file *os.File) (string, error) {
+	if ctype := mime.TypeByExtension(filepath.Ext(file.Name())); ctype != "" {
+		return ctype, nil
+	}
+	var buf [sniffLen]byte
+	n, err := file.ReadAt(buf[:], 0)
+	if nil != err && err != io.EOF {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+func contentRange(ra bodyPart, size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", ra.offset, ra.offset+ra.length-1, size)
+}
+
+// checkPreconditions return 304 or 412 when the conditional request is not satisfied, otherwise 0.
+func checkPreconditions(r *http.Request, etag string, modtime time.Time) int {
+	modtime = modtime.Truncate(time.Second)
+	if im := r.Header.Get("If-Match"); im != "" {
+		if !etagMatch(im, etag, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if t, err := http.ParseTime(r.Header.Get("If-Unmodified-Since")); nil == err && modtime.After(t) {
+		return http.StatusPreconditionFailed
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etagMatch(inm, etag, true) {
+			return http.StatusNotModified
+		}
+	} else if t, err := http.ParseTime(r.Header.Get("If-Modified-Since")); nil == err && !modtime.After(t) {
+		return http.StatusNotModified
+	}
+	return 0
+}
+
+// checkIfRange return true when the Range header should be used.
+func checkIfRange(r *http.Request, etag string, modtime time.Time) bool {
+	ir := r.Header.Get("If-Range")
+	if ir == "" {
+		return true
+	}
+	if strings.HasPrefix(ir, `"`) || strings.HasPrefix(ir, "W/") {
+		return ir == etag
+	}
+	t, err := http.ParseTime(ir)
+	return nil == err && t.Equal(modtime.Truncate(time.Second))
+}
+
+// etagMatch check the etag in the list of If-Match or If-None-Match,
+// the weak comparison ignore the W/ prefix, the strong one never match the weak tags.
+func etagMatch(list, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = textproto.TrimString(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRange parse the Range header like "bytes=0-99,200-,-50",
+// the ranges start after size are ignored, it's an error when no range left.
+func parseRange(s string, size int64) ([]bodyPart, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(s, prefix) {
+		return nil, errUnsatisfiableRange
+	}
+	var ranges []bodyPart
+	for _, spec := range strings.Split(s[len(prefix):], ",") {
+		spec = textproto.TrimString(spec)
+		if spec == "" {
+			continue
+		}
+		i := strings.Index(spec, "-")
+		if i < 0 {
+			return nil, errUnsatisfiableRange
+		}
+		start, end := textproto.TrimString(spec[:i]), textproto.TrimString(spec[i+1:])
+		var ra bodyPart
+		if start == "" {
+			// suffix range, the last n bytes.
+			n, err := strconv.ParseInt(end, 10, 64)
+			if nil != err || n < 0 {
+				return nil, errUnsatisfiableRange
+			}
+			if n > size {
+				n = size
+			}
+			ra.offset, ra.length = size-n, n
+		} else {
+			first, err := strconv.ParseInt(start, 10, 64)
+			if nil != err || first < 0 {
+				return nil, errUnsatisfiableRange
+			}
+			if first >= size {
+				continue
+			}
+			last := size - 1
+			if end != "" {
+				if last, err = strconv.ParseInt(end, 10, 64); nil != err || last < first {
+					return nil, errUnsatisfiableRange
+				}
+				if last >= size {
+					last = size - 1
+				}
+			}
+			ra.offset, ra.length = first, last-first+1
+		}
+		if ra.length > 0 {
+			ranges = append(ranges, ra)
+		}
+	}
+	if len(ranges) == 0 {
+		return nil, errUnsatisfiableRange
+	}
+	return ranges, nil
+}
@@ -0,0 +1,158 @@
+package sendfile
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func createServeDir(t *testing.T) (string, []byte) {
+	dir, err := ioutil.TempDir("", "sendfile-server")
+	if nil != err {
+		t.Fatal(err)
+	}
+	data := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(data)
+	copy(data, "<html>")
+	ioutil.WriteFile(filepath.Join(dir, "artifact"), data, 0644)
+	ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("index"), 0644)
+	return dir, data
+}
+
+func doRequest(t *testing.T, url string, header map[string]string) (*http.Response, []byte) {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		t.Fatal(err)
+	}
+	return resp, body
+}
+
+func TestFileHandler(t *testing.T) {
+	dir, data := createServeDir(t)
+	defer os.RemoveAll(dir)
+
+	// zero-copy by the ReadFrom of response writer, and copy by ReadAt.
+	for _, minSize := range []int64{1, -1} {
+		var conns int32
+		server := httptest.NewUnstartedServer(&FileHandler{Root: dir, ZeroCopyMinSize: minSize})
+		server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				atomic.AddInt32(&conns, 1)
+			}
+		}
+		server.Start()
+		url := server.URL + "/artifact"
+
+		resp, body := doRequest(t, url, nil)
+		if resp.StatusCode != http.StatusOK || !bytes.Equal(body, data) {
+			t.Errorf("unexpected status %v, body length %v", resp.StatusCode, len(body))
+		}
+		if resp.Header.Get("Content-Type") != "text/html; charset=utf-8" {
+			t.Errorf("unexpected sniffed content type %v", resp.Header.Get("Content-Type"))
+		}
+		etag := resp.Header.Get("ETag")
+		lastModified := resp.Header.Get("Last-Modified")
+
+		resp, body = doRequest(t, url, map[string]string{"Range": "bytes=100-199"})
+		if resp.StatusCode != http.StatusPartialContent || !bytes.Equal(body, data[100:200]) ||
+			resp.Header.Get("Content-Range") != "bytes 100-199/100000" {
+			t.Errorf("unexpected status %v, content range %v", resp.StatusCode, resp.Header.Get("Content-Range"))
+		}
+		resp, body = doRequest(t, url, map[string]string{"Range": "bytes=-10"})
+		if !bytes.Equal(body, data[len(data)-10:]) {
+			t.Errorf("unexpected suffix range %v", resp.Header.Get("Content-Range"))
+		}
+
+		resp, body = doRequest(t, url, map[string]string{"Range": "bytes=0-9, 50000-, 99990-99999999"})
+		mediaType, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if resp.StatusCode != http.StatusPartialContent || mediaType != "multipart/byteranges" {
+			t.Fatalf("unexpected status %v, content type %v", resp.StatusCode, mediaType)
+		}
+		reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for _, expected := range [][2]int{{0, 10}, {50000, 100000}, {99990, 100000}} {
+			part, err := reader.NextPart()
+			if nil != err {
+				t.Fatal(err)
+			}
+			content, _ := ioutil.ReadAll(part)
+			if !bytes.Equal(content, data[expected[0]:expected[1]]) {
+				t.Errorf("unexpected part %v", part.Header.Get("Content-Range"))
+			}
+		}
+		if _, err := reader.NextPart(); nil == err {
+			t.Error("expected end of parts")
+		}
+
+		resp, _ = doRequest(t, url, map[string]string{"Range": "bytes=200000-"})
+		if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable || resp.Header.Get("Content-Range") != "bytes */100000" {
+			t.Errorf("unexpected status %v", resp.StatusCode)
+		}
+
+		resp, _ = doRequest(t, url, map[string]string{"If-None-Match": `"other", ` + etag})
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("expected not modified, but %v", resp.StatusCode)
+		}
+		resp, _ = doRequest(t, url, map[string]string{"If-Modified-Since": lastModified})
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("expected not modified, but %v", resp.StatusCode)
+		}
+		resp, _ = doRequest(t, url, map[string]string{"If-Match": `"other"`})
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("expected precondition failed, but %v", resp.StatusCode)
+		}
+		resp, _ = doRequest(t, url, map[string]string{"If-Unmodified-Since": time.Unix(0, 0).UTC().Format(http.TimeFormat)})
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("expected precondition failed, but %v", resp.StatusCode)
+		}
+
+		resp, body = doRequest(t, url, map[string]string{"Range": "bytes=0-9", "If-Range": etag})
+		if resp.StatusCode != http.StatusPartialContent || len(body) != 10 {
+			t.Errorf("expected partial content of matched If-Range, but %v", resp.StatusCode)
+		}
+		resp, body = doRequest(t, url, map[string]string{"Range": "bytes=0-9", "If-Range": `"other"`})
+		if resp.StatusCode != http.StatusOK || len(body) != len(data) {
+			t.Errorf("expected whole file of not matched If-Range, but %v", resp.StatusCode)
+		}
+
+		resp, body = doRequest(t, server.URL+"/", nil)
+		if string(body) != "index" {
+			t.Errorf("unexpected index page %q", body)
+		}
+		resp, _ = doRequest(t, server.URL+"/../../etc/passwd", nil)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected not found, but %v", resp.StatusCode)
+		}
+		if n := atomic.LoadInt32(&conns); n != 1 {
+			t.Errorf("expected the connection kept alive, but %v connections", n)
+		}
+
+		resp, err := http.Head(url)
+		if nil != err || resp.ContentLength != int64(len(data)) {
+			t.Errorf("unexpected head content length %v, err: %v", resp.ContentLength, err)
+		}
+		resp, err = http.Post(url, "text/plain", nil)
+		if nil != err || resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("expected method not allowed, but %v, err: %v", resp.StatusCode, err)
+		}
+		server.Close()
+	}
+}
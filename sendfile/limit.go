@@ -0,0 +1,143 @@
+package sendfile
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket limits the bytes per second, it's safe for concurrent use,
+// share it by many transfers to limit the total bandwidth.
+// The waiters take tokens in advance, so the later waiters wait longer, no transfer is starved.
+type Limiter struct {
+	mutex  sync.Mutex
+	rate   float64 // bytes per second, not positive means unlimited.
+	burst  int64
+	tokens float64
+	last   time.Time
+}
+
+// Transfer send file ranges with optional Limiter and Progress callback,
+// the bytes are sent by SendfileConn in chunks, so it's still zero-copy.
+type Transfer struct {
+	Limiter   *Limiter
+	ChunkSize int64 // bytes of every chunk, default 256 KB, no more than the burst of Limiter.
+
+	// Progress is called after every chunk sent.
+	Progress func(sent, total int64)
+}
+
+const defaultChunkSize = 256 * 1024
+
+// NewLimiter return a Limiter of bytesPerSec, and allow burst bytes at once, burst default to bytesPerSec.
+// The bucket is full at the beginning.
+func NewLimiter(bytesPerSec, burst int64) *Limiter {
+	if burst <= 0 {
+		burst = bytesPerSec
+	}
+	return &Limiter{
+		rate:   float64(bytesPerSec),
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// SetRate change the bytes per second, not positive means unlimited.
+func (l *Limiter) SetRate(bytesPerSec int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.advance(time.Now())
+	l.rate = float64(bytesPerSec)
+}
+
+// Burst return the max bytes allowed at once.
+func (l *Limiter) Burst() int64 {
+	return l.burst
+}
+
+// advance add the tokens since last time, and cap it by burst.
+func (l *Limiter) advance(now time.Time) {
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+	}
+	l.last = now
+}
+
+// WaitN block until n bytes are allowed or ctx is done.
+// n can be larger than burst, it waits the bucket refilled.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	l.mutex.Lock()
+	if l.rate <= 0 {
+		l.mutex.Unlock()
+		return ctx.Err()
+	}
+	now := time.Now()
+	l.advance(now)
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mutex.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give back the tokens not used.
+		l.mutex.Lock()
+		l.tokens += float64(n)
+		l.mutex.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Send send length bytes of file from offset to conn, return the bytes sent and the last method used.
+func (t *Transfer) Send(ctx context.Context, conn net.Conn, file *os.File, offset, length int64) (int64, Method, error) {
+	chunkSize := t.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	if nil != t.Limiter && t.Limiter.Burst() > 0 && chunkSize > t.Limiter.Burst() {
+		chunkSize = t.Limiter.Burst()
+	}
+
+	var sent int64
+	method := MethodSendfile
+	for sent < length {
+		n := length - sent
+		if n > chunkSize {
+			n = chunkSize
+		}
+		if nil != t.Limiter {
+			if err := t.Limiter.WaitN(ctx, int(n)); nil != err {
+				return sent, method, err
+			}
+		} else if err := ctx.Err(); nil != err {
+			return sent, method, err
+		}
+
+		var m int64
+		var err error
+		m, method, err = SendfileConn(conn, file, offset+sent, n)
+		sent += m
+		if nil != t.Progress && m > 0 {
+			t.Progress(sent, length)
+		}
+		if nil != err {
+			return sent, method, err
+		}
+	}
+	return sent, method, nil
+}
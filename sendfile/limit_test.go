@@ -0,0 +1,94 @@
+package sendfile
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiter_WaitN(t *testing.T) {
+	l := NewLimiter(100*1024, 10*1024)
+	ctx := context.Background()
+
+	// the burst is allowed at once, then 40 KB need 400ms, shared by 4 goroutines.
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if err := l.WaitN(ctx, 2560); nil != err {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond || elapsed > time.Second {
+		t.Errorf("unexpected elapsed %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := l.WaitN(ctx, 100*1024); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, but %v", err)
+	}
+
+	l.SetRate(0)
+	start = time.Now()
+	if err := l.WaitN(context.Background(), 1<<30); nil != err || time.Since(start) > 10*time.Millisecond {
+		t.Errorf("expected unlimited, but %v, err: %v", time.Since(start), err)
+	}
+}
+
+func TestTransfer_Send(t *testing.T) {
+	file, data := createRangeFile(t, 200*1024)
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	var progress []int64
+	transfer := &Transfer{
+		Limiter:   NewLimiter(400*1024, 32*1024),
+		ChunkSize: 64 * 1024,
+		Progress: func(sent, total int64) {
+			if total != 150*1024 {
+				t.Errorf("unexpected total %v", total)
+			}
+			progress = append(progress, sent)
+		},
+	}
+
+	start := time.Now()
+	received, n, err := sendAndReceive(t, func(conn *net.TCPConn) (int64, error) {
+		n, method, err := transfer.Send(context.Background(), conn, file, 1000, 150*1024)
+		if method != MethodSendfile {
+			t.Errorf("expected sendfile, but %v", method)
+		}
+		return n, err
+	}, 0)
+	if nil != err || n != 150*1024 || !bytes.Equal(received, data[1000:1000+150*1024]) {
+		t.Errorf("unexpected sent %v, received %v, err: %v", n, len(received), err)
+	}
+	// the chunks are limited by burst, 150 KB - 32 KB burst need about 300ms.
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("unexpected elapsed %v", elapsed)
+	}
+	if len(progress) != 5 || progress[4] != 150*1024 {
+		t.Errorf("unexpected progress %v", progress)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, n, err = sendAndReceive(t, func(conn *net.TCPConn) (int64, error) {
+		n, _, err := (&Transfer{}).Send(ctx, conn, file, 0, 1000)
+		return n, err
+	}, 0)
+	if err != context.Canceled || n != 0 {
+		t.Errorf("expected canceled, but %v, err: %v", n, err)
+	}
+}
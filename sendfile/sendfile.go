@@ -2,10 +2,54 @@ package sendfile
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"sync"
+	"syscall"
 )
 
+// Method is the way a file is sent.
+type Method int
+
+const (
+	MethodSendfile      Method = iota // zero-copy by sendfile(2).
+	MethodCopy                        // copy by a pooled buffer in user space.
+	MethodReflink                     // share the extents by FICLONE or FICLONERANGE ioctl.
+	MethodCopyFileRange               // copy in kernel by copy_file_range(2).
+)
+
+func (m Method) String() string {
+	switch m {
+	case MethodSendfile:
+		return "sendfile"
+	case MethodCopy:
+		return "copy"
+	case MethodReflink:
+		return "reflink"
+	case MethodCopyFileRange:
+		return "copy_file_range"
+	default:
+		return fmt.Sprintf("Method(%d)", int(m))
+	}
+}
+
+// ConnUnwrapper is implemented by the net.Conn wrappers, e.g. the connection with metrics,
+// the underlying connection is used to send file with zero-copy.
+// Never implement it on the connections transform the bytes, e.g. TLS.
+type ConnUnwrapper interface {
+	UnwrapConn() net.Conn
+}
+
+const copyBufferSize = 32 * 1024
+
+var copyBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	},
+}
+
 func Sendfile(tcpConn *net.TCPConn, file *os.File) (int64, error) {
 	fstat, err := file.Stat()
 	if nil != err {
@@ -24,3 +68,59 @@ func Sendfile(tcpConn *net.TCPConn, file *os.File) (int64, error) {
 	// ReadFrom use syscall splice and sendfile to send data.
 	return tcpConn.ReadFrom(file)
 }
+
+// SendfileRange send length bytes of file from offset, without changing the file offset,
+// so one opened file can serve many concurrent ranges.
+// It return io.ErrUnexpectedEOF when the file ends before length bytes sent.
+func SendfileRange(tcpConn *net.TCPConn, file *os.File, offset, length int64) (int64, error) {
+	n, _, err := SendfileConn(tcpConn, file, offset, length)
+	return n, err
+}
+
+// SendfileConn send length bytes of file from offset to any net.Conn, without changing the file offset.
+// It use sendfile(2) when the connection is a *net.TCPConn or *net.UnixConn, or unwrapped to them by
+// ConnUnwrapper, otherwise copy by a pooled buffer, and return the method taken.
+// It return io.ErrUnexpectedEOF when the file ends before length bytes sent.
+func SendfileConn(conn net.Conn, file *os.File, offset, length int64) (int64, Method, error) {
+	if offset < 0 || length < 0 {
+		return 0, MethodCopy, fmt.Errorf("invalid range, offset: %v, length: %v, filename: %v", offset, length, file.Name())
+	}
+	if sc := unwrapConn(conn); nil != sc {
+		if length == 0 {
+			return 0, MethodSendfile, nil
+		}
+		n, err := sendfileRange(sc, file, offset, length)
+		if n > 0 || !isSendfileUnsupported(err) {
+			return n, MethodSendfile, err
+		}
+	}
+	n, err := copyRange(conn, file, offset, length)
+	return n, MethodCopy, err
+}
+
+// unwrapConn return the connection supports sendfile, or nil.
+func unwrapConn(conn net.Conn) syscall.Conn {
+	for {
+		switch c := conn.(type) {
+		case *net.TCPConn:
+			return c
+		case *net.UnixConn:
+			return c
+		case ConnUnwrapper:
+			conn = c.UnwrapConn()
+		default:
+			return nil
+		}
+	}
+}
+
+func copyRange(conn net.Conn, file *os.File, offset, length int64) (int64, error) {
+	buf := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(buf)
+	// hide the io.ReaderFrom of conn, so the pooled buffer is used.
+	n, err := io.CopyBuffer(struct{ io.Writer }{conn}, io.NewSectionReader(file, offset, length), *buf)
+	if nil == err && n < length {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
@@ -0,0 +1,97 @@
+package sendfile
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// wrappedConn is a net.Conn wrapper like the connections with metrics.
+type wrappedConn struct {
+	net.Conn
+	unwrap bool
+}
+
+func (c *wrappedConn) UnwrapConn() net.Conn {
+	if !c.unwrap {
+		return nil
+	}
+	return c.Conn
+}
+
+func TestSendfileConn_Unix(t *testing.T) {
+	file, data := createRangeFile(t, 1<<20)
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	dir, err := ioutil.TempDir("", "sendfile-unix")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	listen, err := net.Listen("unix", filepath.Join(dir, "sock"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer listen.Close()
+
+	for _, c := range []struct {
+		wrap, unwrap bool
+		method       Method
+	}{
+		{false, false, MethodSendfile},
+		{true, true, MethodSendfile},
+		{true, false, MethodCopy},
+	} {
+		done := make(chan []byte)
+		go func() {
+			conn, err := net.Dial("unix", listen.Addr().String())
+			if nil != err {
+				t.Error(err)
+				close(done)
+				return
+			}
+			defer conn.Close()
+			received, _ := ioutil.ReadAll(conn)
+			done <- received
+		}()
+
+		conn, err := listen.Accept()
+		if nil != err {
+			t.Fatal(err)
+		}
+		if c.wrap {
+			conn = &wrappedConn{Conn: conn, unwrap: c.unwrap}
+		}
+		n, method, err := SendfileConn(conn, file, 1000, 500000)
+		conn.Close()
+		received := <-done
+		if nil != err || n != 500000 || method != c.method || !bytes.Equal(received, data[1000:501000]) {
+			t.Errorf("unexpected sent %v by %v, received %v, err: %v", n, method, len(received), err)
+		}
+	}
+}
+
+func TestSendfileConn_Pipe(t *testing.T) {
+	file, data := createRangeFile(t, 100000)
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	server, client := net.Pipe()
+	done := make(chan []byte)
+	go func() {
+		received, _ := ioutil.ReadAll(client)
+		done <- received
+	}()
+	n, method, err := SendfileConn(server, file, 10, 99990)
+	server.Close()
+	if nil != err || n != 99990 || method != MethodCopy || method.String() != "copy" {
+		t.Errorf("unexpected sent %v by %v, err: %v", n, method, err)
+	}
+	if received := <-done; !bytes.Equal(received, data[10:]) {
+		t.Errorf("unexpected received %v", len(received))
+	}
+}
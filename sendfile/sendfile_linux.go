@@ -0,0 +1,172 @@
+package sendfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// maxSendfileChunk limit the bytes of once sendfile call, same as the net package.
+const maxSendfileChunk = 4 << 20
+
+// sendfileRange call sendfile(2) with offset pointer, the file offset is not changed.
+// The socket is non-blocking, wait for writable by the net poller when EAGAIN.
+func sendfileRange(conn syscall.Conn, file *os.File, offset, length int64) (int64, error) {
+	dst, err := conn.SyscallConn()
+	if nil != err {
+		return 0, err
+	}
+	src, err := file.SyscallConn()
+	if nil != err {
+		return 0, err
+	}
+
+	var written int64
+	var sendErr, writeErr error
+	err = src.Control(func(infd uintptr) {
+		writeErr = dst.Write(func(outfd uintptr) bool {
+			for written < length {
+				n := length - written
+				if n > maxSendfileChunk {
+					n = maxSendfileChunk
+				}
+				off := offset + written
+				m, err := syscall.Sendfile(int(outfd), int(infd), &off, int(n))
+				if m > 0 {
+					written += int64(m)
+				}
+				switch {
+				case err == syscall.EAGAIN:
+					return false
+				case err == syscall.EINTR:
+					continue
+				case nil != err:
+					sendErr = os.NewSyscallError("sendfile", err)
+					return true
+				case m == 0:
+					sendErr = io.ErrUnexpectedEOF
+					return true
+				}
+			}
+			return true
+		})
+	})
+	if nil == err {
+		err = writeErr
+	}
+	if nil == err {
+		err = sendErr
+	}
+	return written, err
+}
+
+const (
+	spliceMove     = 0x1
+	spliceNonblock = 0x2
+
+	// maxSpliceChunk limit the bytes of once splice call, the pipe hold 64 KB by default.
+	maxSpliceChunk = 1 << 20
+)
+
+// spliceConn move the bytes from src to dst through a pipe by splice(2), until EOF of src.
+// The progress is called with the bytes written to dst.
+func spliceConn(dst, src syscall.Conn, progress func(int64)) (int64, error) {
+	srcRaw, err := src.SyscallConn()
+	if nil != err {
+		return 0, err
+	}
+	dstRaw, err := dst.SyscallConn()
+	if nil != err {
+		return 0, err
+	}
+	var pipe [2]int
+	if err = syscall.Pipe2(pipe[:], syscall.O_NONBLOCK|syscall.O_CLOEXEC); nil != err {
+		return 0, os.NewSyscallError("pipe2", err)
+	}
+	defer syscall.Close(pipe[0])
+	defer syscall.Close(pipe[1])
+
+	var written int64
+	for {
+		var n int64
+		var spliceErr error
+		err = srcRaw.Read(func(fd uintptr) bool {
+			n, spliceErr = splice(int(fd), pipe[1], maxSpliceChunk)
+			return spliceErr != syscall.EAGAIN
+		})
+		if nil == err && nil != spliceErr {
+			err = os.NewSyscallError("splice", spliceErr)
+		}
+		if nil != err {
+			return written, err
+		}
+		if n == 0 {
+			return written, nil
+		}
+
+		// drain the pipe to dst.
+		for n > 0 {
+			var m int64
+			err = dstRaw.Write(func(fd uintptr) bool {
+				m, spliceErr = splice(pipe[0], int(fd), n)
+				return spliceErr != syscall.EAGAIN
+			})
+			if nil == err && nil != spliceErr {
+				err = os.NewSyscallError("splice", spliceErr)
+			}
+			if nil != err {
+				return written, err
+			}
+			n -= m
+			written += m
+			progress(m)
+		}
+	}
+}
+
+func splice(rfd, wfd int, n int64) (int64, error) {
+	for {
+		m, err := syscall.Splice(rfd, nil, wfd, nil, int(n), spliceMove|spliceNonblock)
+		if err == syscall.EINTR {
+			continue
+		}
+		if nil != err {
+			return 0, err
+		}
+		return int64(m), nil
+	}
+}
+
+// setCork set TCP_CORK of the connection, the partial frames are not sent until uncorked.
+func setCork(conn syscall.Conn, on bool) error {
+	raw, err := conn.SyscallConn()
+	if nil != err {
+		return err
+	}
+	value := 0
+	if on {
+		value = 1
+	}
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_CORK, value)
+	})
+	if nil == err && nil != sockErr {
+		err = os.NewSyscallError("setsockopt", sockErr)
+	}
+	return err
+}
+
+// isSendfileUnsupported check the error of sendfile(2) or splice(2) is caused by the socket type, e.g. datagram sockets.
+func isSendfileUnsupported(err error) bool {
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOSYS) || errors.Is(err, syscall.EOPNOTSUPP)
+}
+
+// fileInode return the inode number of file.
+func fileInode(fstat os.FileInfo) uint64 {
+	if stat, ok := fstat.Sys().(*syscall.Stat_t); ok {
+		return stat.Ino
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+//go:build !linux
+// +build !linux
+
+package sendfile
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+var errSendfileUnsupported = errors.New("sendfile and splice are not supported on this platform")
+
+// sendfileRange is not supported, the caller fallback to copy.
+func sendfileRange(conn syscall.Conn, file *os.File, offset, length int64) (int64, error) {
+	return 0, errSendfileUnsupported
+}
+
+// spliceConn is not supported, the caller fallback to copy.
+func spliceConn(dst, src syscall.Conn, progress func(int64)) (int64, error) {
+	return 0, errSendfileUnsupported
+}
+
+func reflink(dst, src *os.File) error {
+	return errSendfileUnsupported
+}
+
+func reflinkRange(dst, src *os.File, dstOffset, srcOffset, length int64) error {
+	return errSendfileUnsupported
+}
+
+func copyFileRange(dst, src *os.File, dstOffset, srcOffset, length int64) (int64, error) {
+	return 0, errSendfileUnsupported
+}
+
+func sendfileFile(dst, src *os.File, dstOffset, srcOffset, length int64) (int64, error) {
+	return 0, errSendfileUnsupported
+}
+
+func isCopyUnsupported(err error) bool {
+	return err == errSendfileUnsupported
+}
+
+// setCork is not supported, the headers are batched by writev only.
+func setCork(conn syscall.Conn, on bool) error {
+	return errSendfileUnsupported
+}
+
+func isSendfileUnsupported(err error) bool {
+	return err == errSendfileUnsupported
+}
+
+// fileInode return 0, the inode is not portable.
+func fileInode(fstat os.FileInfo) uint64 {
+	return 0
+}
@@ -0,0 +1,105 @@
+package sendfile
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// createRangeFile create a temp file of size random bytes.
+func createRangeFile(t *testing.T, size int) (*os.File, []byte) {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(data)
+	file, err := ioutil.TempFile("", "sendfile")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, err = file.Write(data); nil != err {
+		t.Fatal(err)
+	}
+	return file, data
+}
+
+// sendAndReceive send the range on a loopback tcp connection, and return the received bytes.
+func sendAndReceive(t *testing.T, send func(conn *net.TCPConn) (int64, error), delay time.Duration) ([]byte, int64, error) {
+	listen, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer listen.Close()
+
+	var n int64
+	var sendErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn, err := listen.Accept()
+		if nil != err {
+			sendErr = err
+			return
+		}
+		defer conn.Close()
+		n, sendErr = send(conn.(*net.TCPConn))
+	}()
+
+	conn, err := net.Dial("tcp", listen.Addr().String())
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// let the socket buffer full, so sendfile meet EAGAIN.
+	time.Sleep(delay)
+	received, err := ioutil.ReadAll(conn)
+	if nil != err {
+		t.Error(err)
+	}
+	<-done
+	return received, n, sendErr
+}
+
+func TestSendfileRange(t *testing.T) {
+	file, data := createRangeFile(t, 8<<20)
+	defer os.Remove(file.Name())
+	defer file.Close()
+	file.Seek(100, io.SeekStart)
+
+	ranges := [][2]int64{{0, 10}, {1000, 4096}, {12345, 1 << 20}, {0, 8 << 20}, {8<<20 - 1, 1}}
+	var wg sync.WaitGroup
+	for i, r := range ranges {
+		wg.Add(1)
+		go func(i int, offset, length int64) {
+			defer wg.Done()
+			received, n, err := sendAndReceive(t, func(conn *net.TCPConn) (int64, error) {
+				return SendfileRange(conn, file, offset, length)
+			}, time.Duration(i)*50*time.Millisecond)
+			if nil != err || n != length || !bytes.Equal(received, data[offset:offset+length]) {
+				t.Errorf("unexpected range [%v, %v), sent %v, received %v, err: %v", offset, offset+length, n, len(received), err)
+			}
+		}(i, r[0], r[1])
+	}
+	wg.Wait()
+
+	if offset, _ := file.Seek(0, io.SeekCurrent); offset != 100 {
+		t.Errorf("expected file offset unchanged, but %v", offset)
+	}
+
+	received, n, err := sendAndReceive(t, func(conn *net.TCPConn) (int64, error) {
+		return SendfileRange(conn, file, 8<<20-10, 20)
+	}, 0)
+	if err != io.ErrUnexpectedEOF || n != 10 || len(received) != 10 {
+		t.Errorf("expected unexpected EOF after 10 bytes, but %v, err: %v", n, err)
+	}
+
+	_, _, err = sendAndReceive(t, func(conn *net.TCPConn) (int64, error) {
+		return SendfileRange(conn, file, -1, 20)
+	}, 0)
+	if nil == err {
+		t.Error("expected invalid range error")
+	}
+}
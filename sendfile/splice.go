@@ -0,0 +1,147 @@
+package sendfile
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// Proxy forward the bytes between two connections in both directions by splice(2) through a pipe,
+// the bytes are never copied to user space. It fallback to copy by a pooled buffer when the connections
+// are not TCP or Unix connections, or splice is unsupported.
+//
+// When one direction ends, the write side of it's destination is closed by CloseWrite, and the other
+// direction continues. The connections don't support half-close are closed after both directions end.
+type Proxy struct {
+	// the counters are updated while forwarding, read them by atomic.LoadInt64.
+	Sent     int64 // bytes from src to dst.
+	Received int64 // bytes from dst to src.
+
+	// IdleTimeout close both connections when no byte is forwarded in both directions
+	// for the duration, 0 disable it.
+	IdleTimeout time.Duration
+
+	lastActive int64 // unix nano.
+}
+
+var ErrIdleTimeout = errors.New("sendfile: proxy is idle timeout")
+
+// Splice forward the bytes between dst and src until both directions end, then close them.
+// It return the bytes sent from src to dst, and received from dst to src.
+func Splice(dst, src net.Conn) (sent int64, received int64, err error) {
+	p := &Proxy{}
+	err = p.Splice(dst, src)
+	return p.Sent, p.Received, err
+}
+
+// Splice forward the bytes between dst and src until both directions end, then close them.
+// It return the first error of both directions, or ErrIdleTimeout.
+func (p *Proxy) Splice(dst, src net.Conn) error {
+	atomic.StoreInt64(&p.lastActive, time.Now().UnixNano())
+	done := make(chan struct{})
+	var timedOut int32
+	if p.IdleTimeout > 0 {
+		go p.watchIdle(dst, src, done, &timedOut)
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- p.forward(dst, src, &p.Sent) }()
+	go func() { errs <- p.forward(src, dst, &p.Received) }()
+
+	var err error
+	for i := 0; i < 2; i++ {
+		if e := <-errs; nil != e && nil == err {
+			err = e
+			// unblock the other direction.
+			dst.Close()
+			src.Close()
+		}
+	}
+	close(done)
+	dst.Close()
+	src.Close()
+
+	if atomic.LoadInt32(&timedOut) == 1 {
+		return ErrIdleTimeout
+	}
+	return err
+}
+
+func (p *Proxy) watchIdle(dst, src net.Conn, done chan struct{}, timedOut *int32) {
+	timer := time.NewTimer(p.IdleTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+			idle := time.Since(time.Unix(0, atomic.LoadInt64(&p.lastActive)))
+			if idle >= p.IdleTimeout {
+				atomic.StoreInt32(timedOut, 1)
+				dst.Close()
+				src.Close()
+				return
+			}
+			timer.Reset(p.IdleTimeout - idle)
+		}
+	}
+}
+
+// forward the bytes from src to dst, and close the write side of dst at the end.
+func (p *Proxy) forward(dst, src net.Conn, counter *int64) error {
+	progress := func(n int64) {
+		atomic.AddInt64(counter, n)
+		atomic.StoreInt64(&p.lastActive, time.Now().UnixNano())
+	}
+
+	var err error
+	spliced := false
+	if d, s := unwrapConn(dst), unwrapConn(src); nil != d && nil != s {
+		var n int64
+		n, err = spliceConn(d, s, progress)
+		spliced = n > 0 || !isSendfileUnsupported(err)
+	}
+	if !spliced {
+		err = copyConn(dst, src, progress)
+	}
+	if nil != err {
+		return err
+	}
+	return closeWrite(dst)
+}
+
+// progressWriter report the written bytes.
+type progressWriter struct {
+	io.Writer
+	progress func(int64)
+}
+
+func (w progressWriter) Write(b []byte) (int, error) {
+	n, err := w.Writer.Write(b)
+	w.progress(int64(n))
+	return n, err
+}
+
+func copyConn(dst, src net.Conn, progress func(int64)) error {
+	buf := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(buf)
+	// hide the io.WriterTo of src, so the pooled buffer is used.
+	_, err := io.CopyBuffer(progressWriter{dst, progress}, struct{ io.Reader }{src}, *buf)
+	return err
+}
+
+// closeWrite half-close the connection, it's a no-op when half-close is unsupported.
+func closeWrite(conn net.Conn) error {
+	for {
+		if c, ok := conn.(interface{ CloseWrite() error }); ok {
+			return c.CloseWrite()
+		}
+		u, ok := conn.(ConnUnwrapper)
+		if !ok || nil == u.UnwrapConn() {
+			return nil
+		}
+		conn = u.UnwrapConn()
+	}
+}
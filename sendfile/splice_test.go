@@ -0,0 +1,161 @@
+package sendfile
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// halfCloseConn hide the underlying connection, but support half-close.
+type halfCloseConn struct {
+	net.Conn
+}
+
+func (c halfCloseConn) CloseWrite() error {
+	return c.Conn.(*net.TCPConn).CloseWrite()
+}
+
+// startEcho start a tcp server echoes the bytes, and half-close after the client half-closed.
+func startEcho(t *testing.T) net.Listener {
+	listen, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listen.Accept()
+			if nil != err {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+				conn.(*net.TCPConn).CloseWrite()
+			}()
+		}
+	}()
+	return listen
+}
+
+// startProxy start a tcp proxy to upstream, the results of Splice are sent to results.
+func startProxy(t *testing.T, upstream string, wrap func(net.Conn) net.Conn, p *Proxy, results chan error) net.Listener {
+	listen, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	go func() {
+		src, err := listen.Accept()
+		if nil != err {
+			return
+		}
+		dst, err := net.Dial("tcp", upstream)
+		if nil != err {
+			results <- err
+			return
+		}
+		results <- p.Splice(wrap(dst), wrap(src))
+	}()
+	return listen
+}
+
+func TestProxy_Splice(t *testing.T) {
+	echo := startEcho(t)
+	defer echo.Close()
+	data := make([]byte, 4<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	wraps := []func(net.Conn) net.Conn{
+		func(conn net.Conn) net.Conn { return conn },
+		// not unwrappable, fallback to copy.
+		func(conn net.Conn) net.Conn { return halfCloseConn{conn} },
+	}
+	for i, wrap := range wraps {
+		p := &Proxy{}
+		results := make(chan error, 1)
+		proxy := startProxy(t, echo.Addr().String(), wrap, p, results)
+
+		conn, err := net.Dial("tcp", proxy.Addr().String())
+		if nil != err {
+			t.Fatal(err)
+		}
+		go func() {
+			conn.Write(data)
+			conn.(*net.TCPConn).CloseWrite()
+		}()
+		received, err := ioutil.ReadAll(conn)
+		conn.Close()
+		if nil != err || !bytes.Equal(received, data) {
+			t.Errorf("unexpected echo %v bytes of wrap %v, err: %v", len(received), i, err)
+		}
+
+		if err = <-results; nil != err {
+			t.Errorf("unexpected splice error of wrap %v: %v", i, err)
+		}
+		if atomic.LoadInt64(&p.Sent) != int64(len(data)) || atomic.LoadInt64(&p.Received) != int64(len(data)) {
+			t.Errorf("unexpected counters sent %v, received %v", p.Sent, p.Received)
+		}
+		proxy.Close()
+	}
+}
+
+func TestProxy_IdleTimeout(t *testing.T) {
+	echo := startEcho(t)
+	defer echo.Close()
+
+	p := &Proxy{IdleTimeout: 200 * time.Millisecond}
+	results := make(chan error, 1)
+	proxy := startProxy(t, echo.Addr().String(), func(conn net.Conn) net.Conn { return conn }, p, results)
+	defer proxy.Close()
+
+	conn, err := net.Dial("tcp", proxy.Addr().String())
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	start := time.Now()
+	// the activities keep it alive.
+	buf := make([]byte, 5)
+	for i := 0; i < 3; i++ {
+		time.Sleep(100 * time.Millisecond)
+		conn.Write([]byte("hello"))
+		io.ReadFull(conn, buf)
+	}
+
+	select {
+	case err = <-results:
+		if err != ErrIdleTimeout || time.Since(start) < 400*time.Millisecond {
+			t.Errorf("unexpected error %v after %v", err, time.Since(start))
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("expected idle timeout")
+	}
+	if p.Sent != 15 || p.Received != 15 {
+		t.Errorf("unexpected counters sent %v, received %v", p.Sent, p.Received)
+	}
+}
+
+func TestSplice_Pipe(t *testing.T) {
+	a, b := net.Pipe()
+	c, d := net.Pipe()
+	go func() {
+		b.Write([]byte("ping"))
+		buf := make([]byte, 5)
+		io.ReadFull(b, buf)
+		b.Close()
+	}()
+	go func() {
+		buf := make([]byte, 4)
+		io.ReadFull(c, buf)
+		c.Write([]byte("pong!"))
+		c.Close()
+	}()
+	sent, received, err := Splice(d, a)
+	if nil != err || sent != 4 || received != 5 {
+		t.Errorf("unexpected sent %v, received %v, err: %v", sent, received, err)
+	}
+}
@@ -0,0 +1,60 @@
+package sendfile
+
+import (
+	"net"
+	"os"
+	"time"
+)
+
+// Stats is the statistics of a transfer.
+type Stats struct {
+	Bytes    int64
+	Duration time.Duration
+	Method   Method
+
+	// TCPInfo is the snapshot after transfer, nil when it's not a TCP connection or unsupported.
+	TCPInfo *TCPInfo
+}
+
+// TCPInfo is a snapshot of the kernel TCP_INFO of a connection.
+type TCPInfo struct {
+	State        uint8
+	RTT          time.Duration // smoothed round trip time.
+	RTTVar       time.Duration
+	RTO          time.Duration // retransmission timeout.
+	SndMSS       uint32
+	SndCwnd      uint32 // congestion window in segments.
+	SndSsthresh  uint32
+	Unacked      uint32 // segments sent but not acked.
+	Lost         uint32
+	Retransmits  uint32 // consecutive retransmits of the current timeout.
+	TotalRetrans uint32
+}
+
+// Throughput return the bytes per second.
+func (s *Stats) Throughput() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.Duration.Seconds()
+}
+
+// ReadTCPInfo read the TCP_INFO of the connection, it's only supported on linux.
+func ReadTCPInfo(conn *net.TCPConn) (*TCPInfo, error) {
+	return readTCPInfo(conn)
+}
+
+// SendfileStats send the range like SendfileConn, and return the statistics, even if it failed.
+func SendfileStats(conn net.Conn, file *os.File, offset, length int64) (*Stats, error) {
+	start := time.Now()
+	n, method, err := SendfileConn(conn, file, offset, length)
+	stats := &Stats{
+		Bytes:    n,
+		Duration: time.Since(start),
+		Method:   method,
+	}
+	if tcpConn, ok := unwrapConn(conn).(*net.TCPConn); ok {
+		stats.TCPInfo, _ = readTCPInfo(tcpConn)
+	}
+	return stats, err
+}
@@ -0,0 +1,39 @@
+package sendfile
+
+import (
+	"net"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSendfileStats(t *testing.T) {
+	file, _ := createRangeFile(t, 1<<20)
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	var stats *Stats
+	received, _, err := sendAndReceive(t, func(conn *net.TCPConn) (int64, error) {
+		var err error
+		stats, err = SendfileStats(conn, file, 0, 1<<20)
+		return stats.Bytes, err
+	}, 0)
+	if nil != err || len(received) != 1<<20 || stats.Bytes != 1<<20 || stats.Method != MethodSendfile {
+		t.Fatalf("unexpected stats %+v, err: %v", stats, err)
+	}
+	if stats.Duration <= 0 || stats.Throughput() <= 0 {
+		t.Errorf("unexpected duration %v", stats.Duration)
+	}
+
+	if runtime.GOOS != "linux" || runtime.GOARCH == "386" {
+		return
+	}
+	// the established state is 1.
+	if nil == stats.TCPInfo || stats.TCPInfo.State != 1 || stats.TCPInfo.SndMSS == 0 || stats.TCPInfo.SndCwnd == 0 {
+		t.Errorf("unexpected tcp info %+v", stats.TCPInfo)
+	}
+	if stats.TCPInfo.RTT <= 0 || stats.TCPInfo.RTT > time.Second {
+		t.Errorf("unexpected rtt %v", stats.TCPInfo.RTT)
+	}
+}
@@ -0,0 +1,47 @@
+//go:build linux && !386
+// +build linux,!386
+
+package sendfile
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+func readTCPInfo(conn *net.TCPConn) (*TCPInfo, error) {
+	raw, err := conn.SyscallConn()
+	if nil != err {
+		return nil, err
+	}
+	var info syscall.TCPInfo
+	var errno syscall.Errno
+	err = raw.Control(func(fd uintptr) {
+		size := uint32(syscall.SizeofTCPInfo)
+		_, _, errno = syscall.Syscall6(syscall.SYS_GETSOCKOPT, fd, syscall.IPPROTO_TCP, syscall.TCP_INFO,
+			uintptr(unsafe.Pointer(&info)), uintptr(unsafe.Pointer(&size)), 0)
+	})
+	if nil != err {
+		return nil, err
+	}
+	if errno != 0 {
+		return nil, os.NewSyscallError("getsockopt", errno)
+	}
+
+	// the times are in microseconds.
+	return &TCPInfo{
+		State:        info.State,
+		RTT:          time.Duration(info.Rtt) * time.Microsecond,
+		RTTVar:       time.Duration(info.Rttvar) * time.Microsecond,
+		RTO:          time.Duration(info.Rto) * time.Microsecond,
+		SndMSS:       info.Snd_mss,
+		SndCwnd:      info.Snd_cwnd,
+		SndSsthresh:  info.Snd_ssthresh,
+		Unacked:      info.Unacked,
+		Lost:         info.Lost,
+		Retransmits:  uint32(info.Retransmits),
+		TotalRetrans: info.Total_retrans,
+	}, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux || 386
+// +build !linux 386
+
+package sendfile
+
+import (
+	"errors"
+	"net"
+)
+
+func readTCPInfo(conn *net.TCPConn) (*TCPInfo, error) {
+	return nil, errors.New("TCP_INFO is not supported on this platform")
+}